@@ -0,0 +1,198 @@
+package akismet
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// auditTimeout limits time of recording single audit entry.
+const auditTimeout = 5 * time.Second
+
+// Audit actions.
+const (
+	AuditCheck      = "check"
+	AuditSubmitSpam = "submit-spam"
+	AuditSubmitHam  = "submit-ham"
+)
+
+// AuditEntry is a single record of audit log, describing check verdict or feedback sent to Akismet.
+type AuditEntry struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	// Fingerprint identifies comment, see Comment.Fingerprint.
+	Fingerprint string `json:"fingerprint"`
+	// GUID is returned by check, for feedback it's the GUID of check set with WithGUID.
+	GUID string `json:"guid,omitempty"`
+	// Spam is the verdict of check, or the kind of feedback.
+	Spam   bool   `json:"spam"`
+	ProTip string `json:"pro_tip,omitempty"`
+	// Reason is set when verdict was given by local pre-filter.
+	Reason string `json:"reason,omitempty"`
+	// Actor is the user who triggered the call, set with WithActor.
+	Actor string `json:"actor,omitempty"`
+	// TestTag is the tag of test mode, see TestMode.
+	TestTag string `json:"test_tag,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// AuditSink stores audit entries.
+type AuditSink interface {
+	Record(ctx context.Context, entry AuditEntry) error
+}
+
+// AuditQuery selects audit entries, empty fields match all entries. Time range is inclusive.
+type AuditQuery struct {
+	Fingerprint string
+	From        time.Time
+	To          time.Time
+}
+
+// Match returns true when entry is selected by query.
+func (q AuditQuery) Match(entry AuditEntry) bool {
+	if q.Fingerprint != "" && q.Fingerprint != entry.Fingerprint {
+		return false
+	}
+	if !q.From.IsZero() && entry.Time.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && entry.Time.After(q.To) {
+		return false
+	}
+	return true
+}
+
+// WithAudit is client functional option recording each check verdict and feedback into sink. Errors returned by sink
+// don't fail the call, they are passed to onError, which can be nil.
+func WithAudit(sink AuditSink, onError func(err error)) OptFn {
+	return func(c *akismetClient) {
+		c.auditSink = sink
+		c.auditError = onError
+	}
+}
+
+// WithActor is call functional option setting user who triggered the call, i.e. moderator reporting spam, recorded in
+// audit log.
+func WithActor(actor string) CallOpt {
+	return func(o *callOpts) {
+		o.actor = actor
+	}
+}
+
+// WithGUID is call functional option setting GUID returned by check of the comment, so feedback can be related to it
+// in audit log.
+func WithGUID(guid string) CallOpt {
+	return func(o *callOpts) {
+		o.guid = guid
+	}
+}
+
+// audit records entry about the call, when audit is configured. Entry is recorded even if the call was cancelled, with
+// context detached from its cancellation.
+func (a *akismetClient) audit(ctx context.Context, action string, c *Comment, co callOpts, verdict Verdict, err error) {
+	if a.auditSink == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), auditTimeout)
+	defer cancel()
+	entry := AuditEntry{
+		Time:        time.Now().UTC(),
+		Action:      action,
+		Fingerprint: c.Fingerprint(),
+		GUID:        verdict.GUID,
+		Spam:        verdict.Spam,
+		ProTip:      verdict.ProTip,
+		Reason:      verdict.Reason,
+		Actor:       co.actor,
+	}
+	if a.testMode != nil {
+		entry.TestTag = a.testMode.Tag
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if err := a.auditSink.Record(ctx, entry); err != nil && a.auditError != nil {
+		a.auditError(errors.Wrap(err, "error recording audit entry"))
+	}
+}
+
+// JSONLAuditSink stores audit entries in JSONL file, one entry per line.
+type JSONLAuditSink struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewJSONLAuditSink opens file under given path for appending audit entries, creating it if needed.
+func NewJSONLAuditSink(path string) (*JSONLAuditSink, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening audit log")
+	}
+	return &JSONLAuditSink{path: path, file: file}, nil
+}
+
+// Record appends entry to the file.
+func (s *JSONLAuditSink) Record(_ context.Context, entry AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "error encoding audit entry")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return errors.Wrap(err, "error writing audit entry")
+	}
+	return nil
+}
+
+// Query returns entries from the file selected by query.
+func (s *JSONLAuditSink) Query(q AuditQuery) ([]AuditEntry, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening audit log")
+	}
+	defer file.Close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return QueryAudit(file, q)
+}
+
+// Close closes the file.
+func (s *JSONLAuditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// QueryAudit reads JSONL audit log from r and returns entries selected by query.
+func QueryAudit(r io.Reader, q AuditQuery) ([]AuditEntry, error) {
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, errors.Wrapf(err, "error decoding audit entry in line %d", line)
+		}
+		if q.Match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "error reading audit log")
+	}
+	return entries, nil
+}
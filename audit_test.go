@@ -0,0 +1,245 @@
+package akismet
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type auditSinkMock struct {
+	mu      sync.Mutex
+	entries []AuditEntry
+	ctxErrs []error
+	err     error
+}
+
+func (m *auditSinkMock) Record(ctx context.Context, entry AuditEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry.Time = time.Time{}
+	m.entries = append(m.entries, entry)
+	m.ctxErrs = append(m.ctxErrs, ctx.Err())
+	return m.err
+}
+
+func TestAkismetAudit(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/deadbeef/comment-check":
+			w.Header().Set(guidHeader, "some-guid")
+			w.Header().Set(proTipHeader, ProTipDiscard)
+			fmt.Fprint(w, "true")
+		case "/deadbeef/submit-spam":
+			fmt.Fprint(w, spamHamResponse)
+		default:
+			fmt.Fprint(w, "unusual")
+		}
+	}))
+	defer ts.Close()
+
+	comment := &Comment{UserIP: "0.0.0.0", UserAgent: "Mozilla/6.16"}
+	allowed := func(c *Comment) RuleResult { return RuleResult{Decision: RuleHam, Reason: "allowed"} }
+
+	tests := []struct {
+		name      string
+		preFilter []Rule
+		call      func(cli *akismetClient)
+		expected  []AuditEntry
+	}{{
+		name: "check verdict",
+		call: func(cli *akismetClient) {
+			_, _ = cli.Check(context.Background(), comment)
+		},
+		expected: []AuditEntry{{
+			Action: AuditCheck, Fingerprint: comment.Fingerprint(), GUID: "some-guid", Spam: true, ProTip: ProTipDiscard,
+		}},
+	}, {
+		name:      "check verdict given by pre-filter",
+		preFilter: []Rule{allowed},
+		call: func(cli *akismetClient) {
+			_, _ = cli.Check(context.Background(), comment)
+		},
+		expected: []AuditEntry{{Action: AuditCheck, Fingerprint: comment.Fingerprint(), Reason: "allowed"}},
+	}, {
+		name: "feedback with actor",
+		call: func(cli *akismetClient) {
+			_ = cli.SubmitSpam(context.Background(), comment, WithActor("moderator"))
+		},
+		expected: []AuditEntry{{Action: AuditSubmitSpam, Fingerprint: comment.Fingerprint(), Spam: true, Actor: "moderator"}},
+	}, {
+		name: "feedback with GUID of check",
+		call: func(cli *akismetClient) {
+			_ = cli.SubmitSpam(context.Background(), comment, WithGUID("some-guid"))
+		},
+		expected: []AuditEntry{{Action: AuditSubmitSpam, Fingerprint: comment.Fingerprint(), GUID: "some-guid", Spam: true}},
+	}, {
+		name: "failed feedback",
+		call: func(cli *akismetClient) {
+			_ = cli.SubmitHam(context.Background(), comment, WithActor("moderator"))
+		},
+		expected: []AuditEntry{{
+			Action: AuditSubmitHam, Fingerprint: comment.Fingerprint(), Actor: "moderator",
+			Error: "got response: 'unusual': got unusual response",
+		}},
+	}, {
+		name: "invalid comment is recorded with error",
+		call: func(cli *akismetClient) {
+			_, _ = cli.Check(context.Background(), &Comment{})
+		},
+		expected: []AuditEntry{{
+			Action: AuditCheck, Fingerprint: (&Comment{}).Fingerprint(),
+			Error: "error validating comment struct: field user ip is required",
+		}},
+	}, {
+		name: "invalid feedback is recorded with error",
+		call: func(cli *akismetClient) {
+			_ = cli.SubmitHam(context.Background(), comment, WithBlogURL("other-blog"), WithActor("moderator"))
+		},
+		expected: []AuditEntry{{
+			Action: AuditSubmitHam, Fingerprint: comment.Fingerprint(), Actor: "moderator",
+			Error: "error applying call options: parse \"other-blog\": invalid URI for request: incorrect blog url",
+		}},
+	}, {
+		name: "cancelled call is recorded with context not cancelled",
+		call: func(cli *akismetClient) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, _ = cli.Check(ctx, comment)
+		},
+		expected: []AuditEntry{{
+			Action: AuditCheck, Fingerprint: comment.Fingerprint(), Spam: true,
+			Error: "error during comment check request: cannot do HTTP request: Post \"" + ts.URL + "/deadbeef/comment-check\": context canceled",
+		}},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &auditSinkMock{}
+			cli := &akismetClient{
+				key:        "deadbeef",
+				blogUrl:    "http://some-blog.com",
+				httpClient: &http.Client{},
+				akismetUrl: ts.URL + "/%s/%s",
+				preFilter:  tt.preFilter,
+			}
+			WithAudit(sink, nil)(cli)
+			tt.call(cli)
+			if !reflect.DeepEqual(tt.expected, sink.entries) {
+				t.Errorf("Expected audit entries to be '%+v', but got '%+v'", tt.expected, sink.entries)
+			}
+			for _, err := range sink.ctxErrs {
+				if err != nil {
+					t.Errorf("Expected entry to be recorded with context not done, but got '%v'", err)
+				}
+			}
+		})
+	}
+}
+
+func TestAkismetAuditError(t *testing.T) {
+	sinkErr := errors.New("sink error")
+	var reported error
+	cli := &akismetClient{
+		key:       "deadbeef",
+		blogUrl:   "http://some-blog.com",
+		preFilter: []Rule{func(*Comment) RuleResult { return RuleResult{Decision: RuleSpam} }},
+	}
+	WithAudit(&auditSinkMock{err: sinkErr}, func(err error) { reported = err })(cli)
+
+	spam, err := cli.Check(context.Background(), &Comment{UserIP: "0.0.0.0", UserAgent: "Mozilla/6.16"})
+	if err != nil || !spam {
+		t.Errorf("Expected spam verdict without error, but got '%v' and '%v'", spam, err)
+	}
+	if errors.Cause(reported) != sinkErr {
+		t.Errorf("Expected reported error cause to be '%v', but got '%v'", sinkErr, reported)
+	}
+}
+
+func TestJSONLAuditSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "akismet-audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.jsonl")
+
+	base := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	entries := []AuditEntry{
+		{Time: base, Action: AuditCheck, Fingerprint: "first", GUID: "guid-1", Spam: true},
+		{Time: base.Add(time.Hour), Action: AuditCheck, Fingerprint: "second"},
+		{Time: base.Add(2 * time.Hour), Action: AuditSubmitHam, Fingerprint: "first", Actor: "moderator"},
+	}
+	sink, err := NewJSONLAuditSink(path)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got '%v'", err)
+	}
+	for _, entry := range entries[:2] {
+		if err := sink.Record(context.Background(), entry); err != nil {
+			t.Fatalf("Expected error to be nil, but got '%v'", err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Expected error to be nil, but got '%v'", err)
+	}
+	// reopened sink appends to existing log
+	sink, err = NewJSONLAuditSink(path)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got '%v'", err)
+	}
+	defer sink.Close()
+	if err := sink.Record(context.Background(), entries[2]); err != nil {
+		t.Fatalf("Expected error to be nil, but got '%v'", err)
+	}
+
+	tests := []struct {
+		name     string
+		query    AuditQuery
+		expected []AuditEntry
+	}{{
+		name:     "all entries",
+		expected: entries,
+	}, {
+		name:     "by fingerprint",
+		query:    AuditQuery{Fingerprint: "first"},
+		expected: []AuditEntry{entries[0], entries[2]},
+	}, {
+		name:     "by time range",
+		query:    AuditQuery{From: base.Add(time.Hour), To: base.Add(2 * time.Hour)},
+		expected: entries[1:],
+	}, {
+		name:     "by fingerprint and time range",
+		query:    AuditQuery{Fingerprint: "first", To: base.Add(time.Hour)},
+		expected: entries[:1],
+	}, {
+		name:  "no matching entries",
+		query: AuditQuery{From: base.Add(3 * time.Hour)},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := sink.Query(tt.query)
+			if err != nil {
+				t.Fatalf("Expected error to be nil, but got '%v'", err)
+			}
+			if !reflect.DeepEqual(tt.expected, result) {
+				t.Errorf("Expected entries to be '%+v', but got '%+v'", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestQueryAuditInvalidLine(t *testing.T) {
+	_, err := QueryAudit(strings.NewReader(`{"action":"check"}`+"\n{"), AuditQuery{})
+	if err == nil || !strings.HasPrefix(err.Error(), "error decoding audit entry in line 2") {
+		t.Errorf("Expected decoding error in line 2, but got '%v'", err)
+	}
+}
@@ -0,0 +1,162 @@
+package akismet
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// FeedbackItem is a single feedback sent by SubmitBatch, comment is reported as spam or ham depending on label.
+type FeedbackItem struct {
+	ID      string   `json:"id,omitempty"`
+	Spam    bool     `json:"spam"`
+	Comment *Comment `json:"comment"`
+}
+
+// FeedbackOutcome is the result of sending single feedback.
+type FeedbackOutcome struct {
+	Item FeedbackItem
+	Err  error
+}
+
+// BatchProgress describes progress of SubmitBatch run.
+type BatchProgress struct {
+	Total  int
+	Done   int
+	Failed int
+}
+
+// BatchOptions configures SubmitBatch run.
+type BatchOptions struct {
+	// Concurrency is a number of feedbacks sent at the same time, defaults to 1.
+	Concurrency int
+	// RateLimit is a maximum number of requests made per second, zero means no limit.
+	RateLimit float64
+	// OnProgress is called after each item is processed.
+	OnProgress func(p BatchProgress)
+	// ErrorReport receives JSONL of failed items (along with error), including items not sent because the run was
+	// interrupted. It can be read with ReadFeedbackItems to retry them.
+	ErrorReport io.Writer
+	// CallOpts are applied to each call, i.e. WithActor.
+	CallOpts []CallOpt
+}
+
+type batchFailure struct {
+	FeedbackItem
+	Error string `json:"error"`
+}
+
+type batchResult struct {
+	index int
+	err   error
+}
+
+// SubmitBatch sends feedback for each item, with bounded concurrency and rate, and returns outcomes in order of items.
+// Failure of single item doesn't stop the run. Error is returned when run was interrupted or error report couldn't be
+// written, outcomes are returned anyway.
+func (a *akismetClient) SubmitBatch(ctx context.Context, items []FeedbackItem, opts BatchOptions) ([]FeedbackOutcome, error) {
+	outcomes := make([]FeedbackOutcome, len(items))
+	attempted := make([]bool, len(items))
+	progress := BatchProgress{Total: len(items)}
+	var reportErr error
+	// items not sent because the run was interrupted are reported below
+	_ = runWorkers(ctx, poolOptions{concurrency: opts.Concurrency, rateLimit: opts.RateLimit},
+		func(send func(int) error) error {
+			for i := range items {
+				if err := send(i); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		func(index int) batchResult {
+			err := ctx.Err()
+			if err == nil {
+				err = a.submitItem(ctx, items[index], opts.CallOpts)
+			}
+			return batchResult{index: index, err: err}
+		},
+		func(result batchResult) {
+			outcomes[result.index] = FeedbackOutcome{Item: items[result.index], Err: result.err}
+			attempted[result.index] = true
+			progress.Done++
+			if result.err != nil {
+				progress.Failed++
+				if reportErr == nil {
+					reportErr = writeBatchFailure(opts.ErrorReport, items[result.index], result.err)
+				}
+			}
+			if opts.OnProgress != nil {
+				opts.OnProgress(progress)
+			}
+		},
+	)
+
+	for i, item := range items {
+		if attempted[i] {
+			continue
+		}
+		outcomes[i] = FeedbackOutcome{Item: item, Err: ctx.Err()}
+		if reportErr == nil {
+			reportErr = writeBatchFailure(opts.ErrorReport, item, ctx.Err())
+		}
+	}
+	if reportErr != nil {
+		return outcomes, reportErr
+	}
+	return outcomes, ctx.Err()
+}
+
+func (a *akismetClient) submitItem(ctx context.Context, item FeedbackItem, opts []CallOpt) error {
+	if item.Comment == nil {
+		return errors.New("missing comment")
+	}
+	if item.Spam {
+		return a.SubmitSpam(ctx, item.Comment, opts...)
+	}
+	return a.SubmitHam(ctx, item.Comment, opts...)
+}
+
+func writeBatchFailure(w io.Writer, item FeedbackItem, err error) error {
+	if w == nil {
+		return nil
+	}
+	data, encodeErr := json.Marshal(batchFailure{FeedbackItem: item, Error: err.Error()})
+	if encodeErr != nil {
+		return errors.Wrap(encodeErr, "cannot encode failed item")
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return errors.Wrap(err, "error writing error report")
+	}
+	return nil
+}
+
+// ReadFeedbackItems reads JSONL of feedback items, i.e. error report of SubmitBatch, so failed items can be retried.
+func ReadFeedbackItems(r io.Reader) ([]FeedbackItem, error) {
+	var items []FeedbackItem
+	reader := bufio.NewReader(r)
+	line := 0
+	for {
+		data, err := reader.ReadBytes('\n')
+		if len(data) > 0 {
+			line++
+			if data = bytes.TrimSpace(data); len(data) > 0 {
+				item := FeedbackItem{}
+				if err := json.Unmarshal(data, &item); err != nil {
+					return nil, errors.Wrapf(err, "cannot decode line %d", line)
+				}
+				items = append(items, item)
+			}
+		}
+		if err == io.EOF {
+			return items, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading feedback items")
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package akismet
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestAkismetSubmitBatch(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		form, _ := url.ParseQuery(string(body))
+		mu.Lock()
+		calls = append(calls, r.URL.Path+" "+form.Get("comment_content"))
+		mu.Unlock()
+		if form.Get("comment_content") == "bad" {
+			fmt.Fprint(w, "unusual")
+			return
+		}
+		fmt.Fprint(w, spamHamResponse)
+	}))
+	defer ts.Close()
+	cli := &akismetClient{
+		key:        "deadbeef",
+		blogUrl:    "http://some-blog.com",
+		httpClient: &http.Client{},
+		akismetUrl: ts.URL + "/%s/%s",
+	}
+	newComment := func(content string) *Comment {
+		return &Comment{UserIP: "0.0.0.0", UserAgent: "Mozilla/6.16", Content: content}
+	}
+
+	t.Run("outcomes, progress and error report", func(t *testing.T) {
+		calls = nil
+		items := []FeedbackItem{
+			{ID: "1", Spam: true, Comment: newComment("spam")},
+			{ID: "2", Spam: false, Comment: newComment("bad")},
+			{ID: "3", Spam: false, Comment: newComment("ham")},
+			{ID: "4", Spam: true},
+		}
+		var progress []BatchProgress
+		report := &bytes.Buffer{}
+
+		outcomes, err := cli.SubmitBatch(context.Background(), items, BatchOptions{
+			Concurrency: 2,
+			RateLimit:   1000,
+			OnProgress:  func(p BatchProgress) { progress = append(progress, p) },
+			ErrorReport: report,
+		})
+		if err != nil {
+			t.Fatalf("Expected error to be nil, but got '%v'", err)
+		}
+		if len(outcomes) != len(items) {
+			t.Fatalf("Expected %d outcomes, but got %d", len(items), len(outcomes))
+		}
+		expErrs := []string{"", "got response: 'unusual': got unusual response", "", "missing comment"}
+		for i, outcome := range outcomes {
+			if !reflect.DeepEqual(items[i], outcome.Item) {
+				t.Errorf("Expected outcome %d to be for item '%+v', but got '%+v'", i, items[i], outcome.Item)
+			}
+			if (outcome.Err == nil && expErrs[i] != "") || (outcome.Err != nil && outcome.Err.Error() != expErrs[i]) {
+				t.Errorf("Expected outcome %d error to be '%s', but got '%v'", i, expErrs[i], outcome.Err)
+			}
+		}
+		if len(progress) != 4 || progress[3] != (BatchProgress{Total: 4, Done: 4, Failed: 2}) {
+			t.Errorf("Expected progress to end with 4 done and 2 failed, but got '%+v'", progress)
+		}
+		expCalls := []string{"/deadbeef/submit-ham bad", "/deadbeef/submit-ham ham", "/deadbeef/submit-spam spam"}
+		sort.Strings(calls)
+		if !reflect.DeepEqual(expCalls, calls) {
+			t.Errorf("Expected calls to be '%v', but got '%v'", expCalls, calls)
+		}
+
+		failed, err := ReadFeedbackItems(report)
+		if err != nil {
+			t.Fatalf("Expected error to be nil, but got '%v'", err)
+		}
+		ids := []string{}
+		for _, item := range failed {
+			ids = append(ids, item.ID)
+		}
+		sort.Strings(ids)
+		if !reflect.DeepEqual([]string{"2", "4"}, ids) {
+			t.Errorf("Expected failed items to be 2 and 4, but got '%v'", ids)
+		}
+	})
+
+	t.Run("interrupted run reports items that weren't sent", func(t *testing.T) {
+		items := []FeedbackItem{
+			{ID: "1", Spam: true, Comment: newComment("spam")},
+			{ID: "2", Spam: true, Comment: newComment("spam")},
+			{ID: "3", Spam: true, Comment: newComment("spam")},
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		report := &bytes.Buffer{}
+
+		outcomes, err := cli.SubmitBatch(ctx, items, BatchOptions{
+			OnProgress:  func(BatchProgress) { cancel() },
+			ErrorReport: report,
+		})
+		if err != context.Canceled {
+			t.Errorf("Expected error to be '%v', but got '%v'", context.Canceled, err)
+		}
+		if outcomes[0].Err != nil {
+			t.Errorf("Expected first item to be sent, but got '%v'", outcomes[0].Err)
+		}
+		if !strings.Contains(report.String(), `"error":"context canceled"`) {
+			t.Errorf("Expected error report to contain context error, but got '%s'", report.String())
+		}
+		// second item could be taken before cancellation, but the last one can't
+		failed, _ := ReadFeedbackItems(report)
+		if len(failed) == 0 || failed[len(failed)-1].ID != "3" || outcomes[2].Err != context.Canceled {
+			t.Errorf("Expected item 3 in error report, but got '%+v'", failed)
+		}
+	})
+}
@@ -0,0 +1,194 @@
+package akismet
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// BulkOptions configures BulkCheck run.
+type BulkOptions struct {
+	// Concurrency is a number of comments checked at the same time, defaults to 1.
+	Concurrency int
+	// RateLimit is a maximum number of requests made per second, zero means no limit.
+	RateLimit float64
+	// Checkpoint is a path to file in which number of the last processed input line is stored, when set interrupted
+	// run can be resumed from the place it stopped.
+	Checkpoint string
+}
+
+// BulkVerdict is a single line of BulkCheck output.
+type BulkVerdict struct {
+	// Line is a number of input line (starting from 1) verdict refers to.
+	Line   int    `json:"line"`
+	Spam   bool   `json:"spam"`
+	GUID   string `json:"guid,omitempty"`
+	ProTip string `json:"pro_tip,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type bulkJob struct {
+	seq  int
+	line int
+	data []byte
+}
+
+type bulkResult struct {
+	seq     int
+	verdict BulkVerdict
+	// err is set when comment couldn't be checked, i.e. due to network error, so its verdict isn't final.
+	err error
+}
+
+// BulkCheck reads JSONL stream of comments from r, checks them and writes verdicts as JSONL into w, in the same order
+// as they appear in the input. Malformed or invalid comments don't stop the run, but are reported in verdict. Failed
+// check, i.e. due to network error, stops the run after verdicts of preceding comments are written, so it can be
+// retried. When checkpoint is configured, lines that were processed by previous run are skipped, so w should be opened
+// in append mode when resuming.
+func (a *akismetClient) BulkCheck(ctx context.Context, r io.Reader, w io.Writer, opts BulkOptions) error {
+	done, err := readCheckpoint(opts.Checkpoint)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// verdicts are written in input order, and only when the run wasn't interrupted, as verdicts for interrupted
+	// or failed checks must not be marked as done in checkpoint
+	pending := map[int]bulkResult{}
+	next := 0
+	var writeErr, checkErr error
+	readErr := runWorkers(ctx, poolOptions{concurrency: opts.Concurrency, rateLimit: opts.RateLimit},
+		func(send func(bulkJob) error) error {
+			return readBulkJobs(r, done, send)
+		},
+		func(job bulkJob) bulkResult {
+			verdict, err := a.bulkCheckLine(ctx, job)
+			return bulkResult{seq: job.seq, verdict: verdict, err: err}
+		},
+		func(result bulkResult) {
+			pending[result.seq] = result
+			for writeErr == nil && ctx.Err() == nil {
+				result, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				next++
+				if result.err != nil {
+					checkErr = result.err
+					cancel()
+					break
+				}
+				if writeErr = writeBulkVerdict(w, result.verdict, opts.Checkpoint); writeErr != nil {
+					cancel()
+				}
+			}
+		},
+	)
+	if writeErr != nil {
+		return writeErr
+	}
+	if checkErr != nil {
+		return checkErr
+	}
+	if readErr != nil {
+		return readErr
+	}
+	return ctx.Err()
+}
+
+// bulkCheckLine checks comment of single line. Decoding and validation errors are final, so they are reported in
+// verdict, other errors are returned, as check may succeed when retried.
+func (a *akismetClient) bulkCheckLine(ctx context.Context, job bulkJob) (BulkVerdict, error) {
+	result := BulkVerdict{Line: job.line}
+	comment := &Comment{}
+	if err := json.Unmarshal(job.data, comment); err != nil {
+		result.Error = errors.Wrap(err, "cannot decode comment").Error()
+		return result, nil
+	}
+	if err := comment.Validate(); err != nil {
+		result.Error = errors.Wrap(err, "error validating comment struct").Error()
+		return result, nil
+	}
+	verdict, err := a.CheckVerdict(ctx, comment)
+	if err != nil {
+		return result, errors.Wrapf(err, "cannot check comment in line %d", job.line)
+	}
+	result.Spam = verdict.Spam
+	result.GUID = verdict.GUID
+	result.ProTip = verdict.ProTip
+	return result, nil
+}
+
+func readBulkJobs(r io.Reader, skip int, send func(bulkJob) error) error {
+	reader := bufio.NewReader(r)
+	line, seq := 0, 0
+	for {
+		data, err := reader.ReadBytes('\n')
+		if len(data) > 0 {
+			line++
+			data = bytes.TrimSpace(data)
+			if line > skip && len(data) > 0 {
+				if err := send(bulkJob{seq: seq, line: line, data: data}); err != nil {
+					return err
+				}
+				seq++
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "error reading input")
+		}
+	}
+}
+
+func writeBulkVerdict(w io.Writer, verdict BulkVerdict, checkpoint string) error {
+	data, err := json.Marshal(verdict)
+	if err != nil {
+		return errors.Wrap(err, "cannot encode verdict")
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return errors.Wrap(err, "error writing output")
+	}
+	return writeCheckpoint(checkpoint, verdict.Line)
+}
+
+func readCheckpoint(path string) (int, error) {
+	if path == "" {
+		return 0, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.Wrap(err, "cannot read checkpoint")
+	}
+	line, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, errors.Wrap(err, "cannot parse checkpoint")
+	}
+	return line, nil
+}
+
+func writeCheckpoint(path string, line int) error {
+	if path == "" {
+		return nil
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(strconv.Itoa(line)), 0644); err != nil {
+		return errors.Wrap(err, "cannot write checkpoint")
+	}
+	return errors.Wrap(os.Rename(tmp, path), "cannot write checkpoint")
+}
@@ -0,0 +1,244 @@
+package akismet
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAkismetBulkCheck(t *testing.T) {
+	type check func(output string, checkpoint string, err error, t *testing.T)
+	checks := func(cs ...check) []check { return cs }
+
+	hasErrorMsg := func(expMsg string) check {
+		return func(_, _ string, err error, t *testing.T) {
+			t.Helper()
+			if err == nil || err.Error() != expMsg {
+				t.Errorf("Expected error cause to be '%v', but got '%v'", expMsg, err)
+			}
+		}
+	}
+	hasNoError := func(_, _ string, err error, t *testing.T) {
+		t.Helper()
+		if err != nil {
+			t.Errorf("Expected error to be nil, but got '%v'", err)
+		}
+	}
+	hasOutput := func(exp string) check {
+		return func(output, _ string, _ error, t *testing.T) {
+			t.Helper()
+			if output != exp {
+				t.Errorf("Expected output to be \n'%s', but got \n'%s'", exp, output)
+			}
+		}
+	}
+	hasCheckpoint := func(exp string) check {
+		return func(_, checkpoint string, _ error, t *testing.T) {
+			t.Helper()
+			if checkpoint != exp {
+				t.Errorf("Expected checkpoint to be '%s', but got '%s'", exp, checkpoint)
+			}
+		}
+	}
+
+	input := strings.Join([]string{
+		`{"UserIP":"1.1.1.1","UserAgent":"Mozilla/6.16","Content":"spam"}`,
+		``,
+		`{"UserIP":"2.2.2.2","UserAgent":"Mozilla/6.16","Content":"ham"}`,
+		`{"UserIP":"3.3.3.3"}`,
+		`not a json`,
+		`{"UserIP":"4.4.4.4","UserAgent":"Mozilla/6.16","Content":"spam"}`,
+	}, "\n")
+
+	tests := []struct {
+		name       string
+		checkpoint string
+		opts       BulkOptions
+		checks     []check
+	}{{
+		name: "success checking all comments",
+		opts: BulkOptions{Concurrency: 3},
+		checks: checks(
+			hasNoError,
+			hasOutput(`{"line":1,"spam":true,"guid":"1.1.1.1","pro_tip":"discard"}
+{"line":3,"spam":false,"guid":"2.2.2.2"}
+{"line":4,"spam":false,"error":"error validating comment struct: field user agent is required"}
+{"line":5,"spam":false,"error":"cannot decode comment: invalid character 'o' in literal null (expecting 'u')"}
+{"line":6,"spam":true,"guid":"4.4.4.4","pro_tip":"discard"}
+`),
+			hasCheckpoint("6"),
+		),
+	}, {
+		name:       "success resuming from checkpoint",
+		checkpoint: "3",
+		opts:       BulkOptions{RateLimit: 1000},
+		checks: checks(
+			hasNoError,
+			hasOutput(`{"line":4,"spam":false,"error":"error validating comment struct: field user agent is required"}
+{"line":5,"spam":false,"error":"cannot decode comment: invalid character 'o' in literal null (expecting 'u')"}
+{"line":6,"spam":true,"guid":"4.4.4.4","pro_tip":"discard"}
+`),
+			hasCheckpoint("6"),
+		),
+	}, {
+		name:       "error when checkpoint is malformed",
+		checkpoint: "three",
+		checks: checks(
+			hasErrorMsg(`cannot parse checkpoint: strconv.Atoi: parsing "three": invalid syntax`),
+			hasOutput(""),
+			hasCheckpoint("three"),
+		),
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseForm(); err != nil {
+					t.Fatalf("got error parsing request form: '%v'", err)
+				}
+				w.Header().Set("X-akismet-guid", r.PostForm.Get("user_ip"))
+				if r.PostForm.Get("comment_content") == "spam" {
+					w.Header().Set("X-akismet-pro-tip", "discard")
+					fmt.Fprint(w, "true")
+					return
+				}
+				fmt.Fprint(w, "false")
+			}))
+			defer ts.Close()
+
+			dir, err := ioutil.TempDir("", "akismet-bulk")
+			if err != nil {
+				t.Fatalf("got error creating temp dir: '%v'", err)
+			}
+			defer os.RemoveAll(dir)
+			tt.opts.Checkpoint = filepath.Join(dir, "checkpoint")
+			if tt.checkpoint != "" {
+				if err := ioutil.WriteFile(tt.opts.Checkpoint, []byte(tt.checkpoint), 0644); err != nil {
+					t.Fatalf("got error writing checkpoint: '%v'", err)
+				}
+			}
+
+			cli := &akismetClient{
+				key:        "deadbeef",
+				blogUrl:    "http://some-blog.com",
+				httpClient: &http.Client{},
+				akismetUrl: ts.URL + "/%s/%s",
+			}
+			output := &bytes.Buffer{}
+			err = cli.BulkCheck(context.Background(), strings.NewReader(input), output, tt.opts)
+			checkpoint, _ := ioutil.ReadFile(tt.opts.Checkpoint)
+			for _, ch := range tt.checks {
+				ch(output.String(), string(checkpoint), err, t)
+			}
+		})
+	}
+}
+
+// signallingWriter signals each write, so test can wait for output to be written.
+type signallingWriter struct {
+	bytes.Buffer
+	written chan struct{}
+}
+
+func (w *signallingWriter) Write(p []byte) (int, error) {
+	n, err := w.Buffer.Write(p)
+	w.written <- struct{}{}
+	return n, err
+}
+
+func TestAkismetBulkCheckInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	output := &signallingWriter{written: make(chan struct{}, 3)}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("got error parsing request form: '%v'", err)
+		}
+		if r.PostForm.Get("user_ip") == "2.2.2.2" {
+			// interrupt only after the first verdict is written, and never answer the interrupted check
+			<-output.written
+			cancel()
+			<-r.Context().Done()
+			return
+		}
+		fmt.Fprint(w, "false")
+	}))
+	defer ts.Close()
+
+	cli := &akismetClient{
+		key:        "deadbeef",
+		blogUrl:    "http://some-blog.com",
+		httpClient: &http.Client{},
+		akismetUrl: ts.URL + "/%s/%s",
+	}
+	input := `{"UserIP":"1.1.1.1","UserAgent":"Mozilla/6.16"}
+{"UserIP":"2.2.2.2","UserAgent":"Mozilla/6.16"}
+{"UserIP":"3.3.3.3","UserAgent":"Mozilla/6.16"}
+`
+	err := cli.BulkCheck(ctx, strings.NewReader(input), output, BulkOptions{})
+	if err != context.Canceled {
+		t.Errorf("Expected error to be '%v', but got '%v'", context.Canceled, err)
+	}
+	if exp := "{\"line\":1,\"spam\":false}\n"; output.String() != exp {
+		t.Errorf("Expected output to be '%s', but got '%s'", exp, output.String())
+	}
+}
+
+func TestAkismetBulkCheckFailedCheck(t *testing.T) {
+	failing := true
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("got error parsing request form: '%v'", err)
+		}
+		if failing && r.PostForm.Get("user_ip") == "2.2.2.2" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "false")
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "akismet-bulk")
+	if err != nil {
+		t.Fatalf("got error creating temp dir: '%v'", err)
+	}
+	defer os.RemoveAll(dir)
+	opts := BulkOptions{Concurrency: 3, Checkpoint: filepath.Join(dir, "checkpoint")}
+
+	cli := &akismetClient{
+		key:        "deadbeef",
+		blogUrl:    "http://some-blog.com",
+		httpClient: &http.Client{},
+		akismetUrl: ts.URL + "/%s/%s",
+	}
+	input := `{"UserIP":"1.1.1.1","UserAgent":"Mozilla/6.16"}
+{"UserIP":"2.2.2.2","UserAgent":"Mozilla/6.16"}
+{"UserIP":"3.3.3.3","UserAgent":"Mozilla/6.16"}
+`
+	output := &bytes.Buffer{}
+	err = cli.BulkCheck(context.Background(), strings.NewReader(input), output, opts)
+	if err == nil || !strings.HasPrefix(err.Error(), "cannot check comment in line 2: ") {
+		t.Errorf("Expected error of line 2, but got '%v'", err)
+	}
+	if exp := "{\"line\":1,\"spam\":false}\n"; output.String() != exp {
+		t.Errorf("Expected output to be '%s', but got '%s'", exp, output.String())
+	}
+	if checkpoint, _ := ioutil.ReadFile(opts.Checkpoint); string(checkpoint) != "1" {
+		t.Errorf("Expected checkpoint to be '1', but got '%s'", checkpoint)
+	}
+
+	// resumed run retries failed comment
+	failing = false
+	output.Reset()
+	if err := cli.BulkCheck(context.Background(), strings.NewReader(input), output, opts); err != nil {
+		t.Fatalf("Expected error to be nil, but got '%v'", err)
+	}
+	if exp := "{\"line\":2,\"spam\":false}\n{\"line\":3,\"spam\":false}\n"; output.String() != exp {
+		t.Errorf("Expected output to be '%s', but got '%s'", exp, output.String())
+	}
+}
@@ -0,0 +1,107 @@
+package akismet
+
+import (
+	"context"
+	stderr "errors"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrReservedParam indicates that WithParam was used to set parameter managed by the client, i.e. blog.
+var ErrReservedParam = stderr.New("parameter is managed by client")
+
+// clientParams are parameters that cannot be set with WithParam, blog is overridden with WithBlogURL.
+var clientParams = []string{"blog", "key"}
+
+// CallOpt is a type for optional functional parameters of a single call to Akismet's API.
+type CallOpt func(o *callOpts)
+
+type callOpts struct {
+	blogUrl        string
+	params         url.Values
+	timeout        time.Duration
+	isTest         bool
+	skipCache      bool
+	idempotencyKey string
+	actor          string
+	guid           string
+	acceptLanguage string
+	// redacted holds names of parameters changed by redaction policy, it's nil when the policy wasn't applied.
+	redacted []string
+}
+
+func newCallOpts(opts []CallOpt) (callOpts, error) {
+	co := callOpts{}
+	for _, fn := range opts {
+		fn(&co)
+	}
+	if co.blogUrl != "" {
+		if _, err := url.ParseRequestURI(co.blogUrl); err != nil {
+			return co, errors.Wrap(ErrBlogURLIncorrect, err.Error())
+		}
+	}
+	for _, name := range clientParams {
+		if _, ok := co.params[name]; ok {
+			return co, errors.Wrapf(ErrReservedParam, "parameter '%s'", name)
+		}
+	}
+	return co, nil
+}
+
+// context returns context limited by call timeout, if it's set.
+func (co callOpts) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if co.timeout > 0 {
+		return context.WithTimeout(ctx, co.timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// WithBlogURL is call functional option overriding client's blog url, i.e. when single client serves multiple sites.
+func WithBlogURL(blogUrl string) CallOpt {
+	return func(o *callOpts) {
+		o.blogUrl = blogUrl
+	}
+}
+
+// WithParam is call functional option adding parameter to the request, replacing comment's parameter with the same
+// name. Parameters managed by client (blog and key) cannot be set, call fails with ErrReservedParam.
+func WithParam(name, value string) CallOpt {
+	return func(o *callOpts) {
+		if o.params == nil {
+			o.params = url.Values{}
+		}
+		o.params.Set(name, value)
+	}
+}
+
+// WithCallTimeout is call functional option limiting time of the call.
+func WithCallTimeout(timeout time.Duration) CallOpt {
+	return func(o *callOpts) {
+		o.timeout = timeout
+	}
+}
+
+// WithIsTest is call functional option marking comment as test one (is_test=1).
+func WithIsTest() CallOpt {
+	return func(o *callOpts) {
+		o.isTest = true
+	}
+}
+
+// WithSkipCache is call functional option bypassing client-side caching of the call, i.e. deduplication made by
+// FeedbackDeduper. It's passed to interceptors in Call, and it's not sent to Akismet.
+func WithSkipCache() CallOpt {
+	return func(o *callOpts) {
+		o.skipCache = true
+	}
+}
+
+// WithIdempotencyKey is call functional option setting key identifying repeated calls, i.e. by FeedbackDeduper. It's
+// passed to interceptors in Call, and it's not sent to Akismet.
+func WithIdempotencyKey(key string) CallOpt {
+	return func(o *callOpts) {
+		o.idempotencyKey = key
+	}
+}
@@ -0,0 +1,157 @@
+package akismet
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestAkismetCallOpts(t *testing.T) {
+	type check func(payload []byte, header http.Header, err error, t *testing.T)
+	checks := func(cs ...check) []check { return cs }
+
+	hasNoError := func(_ []byte, _ http.Header, err error, t *testing.T) {
+		t.Helper()
+		if err != nil {
+			t.Errorf("Expected error to be nil, but got '%v'", err)
+		}
+	}
+	hasCauseError := func(exp error) check {
+		return func(_ []byte, _ http.Header, err error, t *testing.T) {
+			t.Helper()
+			if errors.Cause(err) != exp {
+				t.Errorf("Expected error cause to be '%v', but got '%v'", exp, err)
+			}
+		}
+	}
+	hasErrorContaining := func(expMsg string) check {
+		return func(_ []byte, _ http.Header, err error, t *testing.T) {
+			t.Helper()
+			if err == nil || !strings.Contains(err.Error(), expMsg) {
+				t.Errorf("Expected error to contain '%v', but got '%v'", expMsg, err)
+			}
+		}
+	}
+	hasPayload := func(expPayload string) check {
+		return func(payload []byte, _ http.Header, _ error, t *testing.T) {
+			t.Helper()
+			if string(payload) != expPayload {
+				t.Errorf("Expected requst payload to be \n'%s', but got \n'%s'", expPayload, string(payload))
+			}
+		}
+	}
+	hasHeader := func(name, exp string) check {
+		return func(_ []byte, header http.Header, _ error, t *testing.T) {
+			t.Helper()
+			if header.Get(name) != exp {
+				t.Errorf("Expected header %s to be '%s', but got '%s'", name, exp, header.Get(name))
+			}
+		}
+	}
+
+	comment := &Comment{UserIP: "0.0.0.0", UserAgent: "Mozilla/6.16"}
+
+	tests := []struct {
+		name   string
+		call   func(cli *akismetClient) error
+		checks []check
+	}{{
+		name: "check with blog url override and extra params",
+		call: func(cli *akismetClient) error {
+			_, err := cli.Check(context.Background(), comment,
+				WithBlogURL("http://other-blog.com"),
+				WithParam("post_id", "1"),
+				WithParam("user_ip", "1.1.1.1"),
+				WithIsTest(),
+			)
+			return err
+		},
+		checks: checks(
+			hasNoError,
+			hasPayload("blog=http%3A%2F%2Fother-blog.com&is_test=1&post_id=1&user_agent=Mozilla%2F6.16&user_ip=1.1.1.1"),
+		),
+	}, {
+		name: "submit spam with skip cache and idempotency key",
+		call: func(cli *akismetClient) error {
+			return cli.SubmitSpam(context.Background(), comment, WithSkipCache(), WithIdempotencyKey("comment-1"))
+		},
+		checks: checks(
+			hasErrorContaining("got unusual response"),
+			hasPayload("blog=http%3A%2F%2Fsome-blog.com&user_agent=Mozilla%2F6.16&user_ip=0.0.0.0"),
+			hasHeader("Cache-Control", ""),
+			hasHeader("Idempotency-Key", ""),
+		),
+	}, {
+		name: "error when blog is set with param",
+		call: func(cli *akismetClient) error {
+			_, err := cli.Check(context.Background(), comment, WithParam("blog", "http://other-blog.com"))
+			return err
+		},
+		checks: checks(
+			hasCauseError(ErrReservedParam),
+			hasPayload(""),
+		),
+	}, {
+		name: "error when key is set with param",
+		call: func(cli *akismetClient) error {
+			return cli.SubmitHam(context.Background(), comment, WithParam("key", "c0ffee"))
+		},
+		checks: checks(
+			hasCauseError(ErrReservedParam),
+			hasPayload(""),
+		),
+	}, {
+		name: "error when call times out",
+		call: func(cli *akismetClient) error {
+			_, err := cli.Check(context.Background(), comment, WithParam("sleep", "1"), WithCallTimeout(time.Millisecond))
+			return err
+		},
+		checks: checks(
+			hasErrorContaining("context deadline exceeded"),
+		),
+	}, {
+		name: "error when blog url override is invalid",
+		call: func(cli *akismetClient) error {
+			return cli.SubmitHam(context.Background(), comment, WithBlogURL("other-blog"))
+		},
+		checks: checks(
+			hasCauseError(ErrBlogURLIncorrect),
+			hasPayload(""),
+		),
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var payload []byte
+			var header http.Header
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer r.Body.Close()
+				payload, _ = ioutil.ReadAll(r.Body)
+				header = r.Header
+				if strings.Contains(string(payload), "sleep=1") {
+					time.Sleep(50 * time.Millisecond)
+				}
+				fmt.Fprint(w, "false")
+			}))
+
+			cli := &akismetClient{
+				key:        "deadbeef",
+				blogUrl:    "http://some-blog.com",
+				httpClient: &http.Client{},
+				akismetUrl: ts.URL + "/%s/%s",
+			}
+			err := tt.call(cli)
+			// closing server waits for handler, which may still be running after the call timed out
+			ts.Close()
+			for _, ch := range tt.checks {
+				ch(payload, header, err, t)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package akismet
+
+import (
+	"bytes"
+	"encoding/json"
+	stderr "errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// CassetteMode selects whether Cassette records real traffic or replays previously recorded one.
+type CassetteMode int
+
+const (
+	// ModeRecord passes requests to the real transport and records request/response pairs.
+	ModeRecord CassetteMode = iota
+	// ModeReplay serves responses from recorded pairs without touching the network.
+	ModeReplay
+)
+
+const (
+	akismetHostSuffix = ".rest.akismet.com"
+	redactedKey       = "REDACTED"
+)
+
+// ErrCassetteNoMatch is returned in replay mode when there is no recorded interaction matching the request.
+var ErrCassetteNoMatch = stderr.New("no recorded interaction matches request")
+
+// Interaction is a single request/response pair stored in cassette. API key is redacted from both URL and form.
+type Interaction struct {
+	URL        string      `json:"url"`
+	Endpoint   string      `json:"endpoint"`
+	Form       string      `json:"form"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+	// Tag is the tag of test mode the interaction was recorded in, see TestMode.
+	Tag string `json:"tag,omitempty"`
+}
+
+// Cassette records Akismet traffic to file, and replays it later, so tests can be run deterministically without network
+// access. Single cassette can be shared by many clients, each of them replays it from the beginning.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+
+	path string
+	mode CassetteMode
+	mu   sync.Mutex
+}
+
+// cassettePlayer is http.RoundTripper routing requests of single client through the cassette. It keeps own replay
+// cursor, so clients sharing the cassette don't consume each other's interactions.
+type cassettePlayer struct {
+	cassette *Cassette
+	next     http.RoundTripper
+
+	mu   sync.Mutex
+	used map[int]bool
+}
+
+// NewCassette returns cassette stored in given path. In replay mode the file is loaded, and it's required to exist.
+func NewCassette(path string, mode CassetteMode) (*Cassette, error) {
+	c := &Cassette{
+		path: path,
+		mode: mode,
+	}
+	if mode != ModeReplay {
+		return c, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read cassette")
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, errors.Wrap(err, "cannot decode cassette")
+	}
+	return c, nil
+}
+
+// WithCassette is client functional option routing all requests through the cassette. The cassette wraps transport
+// of http client once all options are applied, so it doesn't matter where in options list it's placed. In record mode
+// requests are passed to that transport.
+func WithCassette(cassette *Cassette) OptFn {
+	return func(c *akismetClient) {
+		c.cassette = cassette
+	}
+}
+
+// Transport returns http.RoundTripper routing requests through the cassette, with its own replay cursor. In record
+// mode requests are passed to next, or http.DefaultTransport when next is nil.
+func (c *Cassette) Transport(next http.RoundTripper) http.RoundTripper {
+	return &cassettePlayer{
+		cassette: c,
+		next:     next,
+		used:     map[int]bool{},
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (p *cassettePlayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	var form []byte
+	if req.Body != nil {
+		var err error
+		if form, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, errors.Wrap(err, "cannot read request body")
+		}
+		req.Body.Close()
+	}
+	endpoint := path.Base(req.URL.Path)
+	normalizedForm, err := normalizeForm(form)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.cassette.mode == ModeReplay {
+		return p.replay(req, endpoint, normalizedForm)
+	}
+	return p.record(req, form, endpoint, normalizedForm)
+}
+
+// Save writes recorded interactions to the cassette file.
+func (c *Cassette) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "cannot encode cassette")
+	}
+	return errors.Wrap(ioutil.WriteFile(c.path, data, 0644), "cannot write cassette")
+}
+
+// record passes clone of the request to the next transport, as RoundTripper must not modify the request it was given.
+func (p *cassettePlayer) record(req *http.Request, form []byte, endpoint, normalizedForm string) (*http.Response, error) {
+	next := p.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	outReq := req.Clone(req.Context())
+	outReq.Body = ioutil.NopCloser(bytes.NewReader(form))
+	outReq.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(form)), nil
+	}
+	resp, err := next.RoundTrip(outReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read response body")
+	}
+
+	c := p.cassette
+	c.mu.Lock()
+	c.Interactions = append(c.Interactions, Interaction{
+		URL:        redactURL(req.URL),
+		Endpoint:   endpoint,
+		Form:       normalizedForm,
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       string(body),
+		Tag:        testTag(req.Context()),
+	})
+	c.mu.Unlock()
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	resp.Request = req
+	return resp, nil
+}
+
+// replay serves first interaction matching endpoint and form of the request, not yet used by this player.
+func (p *cassettePlayer) replay(req *http.Request, endpoint, normalizedForm string) (*http.Response, error) {
+	p.cassette.mu.Lock()
+	interactions := p.cassette.Interactions
+	p.cassette.mu.Unlock()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, interaction := range interactions {
+		if p.used[i] || interaction.Endpoint != endpoint || interaction.Form != normalizedForm {
+			continue
+		}
+		p.used[i] = true
+		return &http.Response{
+			Status:     http.StatusText(interaction.StatusCode),
+			StatusCode: interaction.StatusCode,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     interaction.Header,
+			Body:       ioutil.NopCloser(strings.NewReader(interaction.Body)),
+			Request:    req,
+		}, nil
+	}
+	return nil, errors.Wrapf(ErrCassetteNoMatch, "endpoint '%s' with form '%s'", endpoint, normalizedForm)
+}
+
+// normalizeForm returns form with sorted keys and redacted API key.
+func normalizeForm(form []byte) (string, error) {
+	values, err := url.ParseQuery(string(form))
+	if err != nil {
+		return "", errors.Wrap(err, "cannot parse request form")
+	}
+	if values.Get("key") != "" {
+		values.Set("key", redactedKey)
+	}
+	return values.Encode(), nil
+}
+
+// redactURL removes API key from Akismet's host, which has format "<key>.rest.akismet.com".
+func redactURL(u *url.URL) string {
+	redacted := *u
+	if strings.HasSuffix(redacted.Host, akismetHostSuffix) {
+		redacted.Host = redactedKey + akismetHostSuffix
+	}
+	return redacted.String()
+}
@@ -0,0 +1,194 @@
+package akismet
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "akismet-cassette")
+	if err != nil {
+		t.Fatalf("got error creating temp dir: '%v'", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cassette.json")
+	comment := &Comment{UserIP: "0.0.0.0", UserAgent: "Mozilla/6.16"}
+
+	recorder, err := NewCassette(path, ModeRecord)
+	if err != nil {
+		t.Fatalf("got error creating cassette: '%v'", err)
+	}
+	transport := &transportMock{
+		roundTripResp: &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{"X-Akismet-Guid": {"d4a3c8a4e0f1"}},
+			Body:       ioutil.NopCloser(bytes.NewBufferString("true")),
+		},
+	}
+	cli, _ := NewAkismet("deadbeef", "http://some-blog.com",
+		WithHttpClient(&http.Client{Transport: transport}),
+		WithCassette(recorder),
+	)
+	verdict, err := cli.CheckVerdict(context.Background(), comment)
+	if err != nil || !verdict.Spam || verdict.GUID != "d4a3c8a4e0f1" {
+		t.Fatalf("Expected recorded spam verdict, but got '%+v' and error '%v'", verdict, err)
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("got error saving cassette: '%v'", err)
+	}
+
+	data, _ := ioutil.ReadFile(path)
+	if strings.Contains(string(data), "deadbeef") {
+		t.Errorf("Expected cassette to have API key redacted, but got '%s'", data)
+	}
+	if !strings.Contains(string(data), "https://REDACTED.rest.akismet.com/1.1/comment-check") {
+		t.Errorf("Expected cassette to contain redacted URL, but got '%s'", data)
+	}
+
+	player, err := NewCassette(path, ModeReplay)
+	if err != nil {
+		t.Fatalf("got error loading cassette: '%v'", err)
+	}
+	cli, _ = NewAkismet("otherkey", "http://some-blog.com", WithCassette(player))
+	verdict, err = cli.CheckVerdict(context.Background(), comment)
+	if err != nil || !verdict.Spam || verdict.GUID != "d4a3c8a4e0f1" {
+		t.Errorf("Expected replayed spam verdict, but got '%+v' and error '%v'", verdict, err)
+	}
+
+	_, err = cli.CheckVerdict(context.Background(), comment)
+	if err == nil || !strings.Contains(err.Error(), ErrCassetteNoMatch.Error()) {
+		t.Errorf("Expected error to contain '%v', but got '%v'", ErrCassetteNoMatch, err)
+	}
+}
+
+func TestCassetteReplayMatching(t *testing.T) {
+	cassette := &Cassette{
+		mode: ModeReplay,
+		Interactions: []Interaction{{
+			Endpoint:   "verify-key",
+			Form:       "blog=http%3A%2F%2Fsome-blog.com&key=REDACTED",
+			StatusCode: 200,
+			Body:       "valid",
+		}, {
+			Endpoint:   "comment-check",
+			Form:       "blog=http%3A%2F%2Fsome-blog.com&user_agent=Mozilla%2F6.16&user_ip=0.0.0.0",
+			StatusCode: 200,
+			Body:       "false",
+		}},
+	}
+
+	tests := []struct {
+		name     string
+		endpoint string
+		form     string
+		expBody  string
+		expErr   error
+	}{{
+		name:     "success matching redacted key with keys in other order",
+		endpoint: "verify-key",
+		form:     "key=deadbeef&blog=http%3A%2F%2Fsome-blog.com",
+		expBody:  "valid",
+	}, {
+		name:     "success matching endpoint and form",
+		endpoint: "comment-check",
+		form:     "user_ip=0.0.0.0&user_agent=Mozilla%2F6.16&blog=http%3A%2F%2Fsome-blog.com",
+		expBody:  "false",
+	}, {
+		name:     "error when form differs",
+		endpoint: "submit-spam",
+		form:     "user_ip=0.0.0.0&user_agent=Mozilla%2F6.16&blog=http%3A%2F%2Fsome-blog.com",
+		expErr:   ErrCassetteNoMatch,
+	}}
+	player := cassette.Transport(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "https://deadbeef.rest.akismet.com/1.1/"+tt.endpoint, strings.NewReader(tt.form))
+			resp, err := player.RoundTrip(req)
+			if errors.Cause(err) != tt.expErr {
+				t.Fatalf("Expected error cause to be '%v', but got '%v'", tt.expErr, err)
+			}
+			if err != nil {
+				return
+			}
+			body, _ := ioutil.ReadAll(resp.Body)
+			if string(body) != tt.expBody {
+				t.Errorf("Expected response body to be '%s', but got '%s'", tt.expBody, body)
+			}
+		})
+	}
+}
+
+func TestCassetteAppliedAfterOptions(t *testing.T) {
+	recorder, _ := NewCassette("", ModeRecord)
+	transport := &transportMock{
+		roundTripResp: &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewBufferString("false")),
+		},
+	}
+	cli, _ := NewAkismet("deadbeef", "http://some-blog.com",
+		WithCassette(recorder),
+		WithHttpClient(&http.Client{}),
+		WithTransport(transport),
+		WithTimeout(time.Second),
+	)
+	if _, err := cli.CheckVerdict(context.Background(), &Comment{UserIP: "0.0.0.0", UserAgent: "Mozilla/6.16"}); err != nil {
+		t.Fatalf("got error checking comment: '%v'", err)
+	}
+	if len(recorder.Interactions) != 1 {
+		t.Errorf("Expected cassette to record 1 interaction, but got %d", len(recorder.Interactions))
+	}
+}
+
+func TestCassetteSharedReplay(t *testing.T) {
+	cassette := &Cassette{
+		mode: ModeReplay,
+		Interactions: []Interaction{{
+			Endpoint:   "comment-check",
+			Form:       "blog=http%3A%2F%2Fsome-blog.com&user_agent=Mozilla%2F6.16&user_ip=0.0.0.0",
+			StatusCode: 200,
+			Body:       "true",
+		}},
+	}
+	comment := &Comment{UserIP: "0.0.0.0", UserAgent: "Mozilla/6.16"}
+
+	for i := 0; i < 2; i++ {
+		cli, _ := NewAkismet("deadbeef", "http://some-blog.com", WithCassette(cassette))
+		spam, err := cli.Check(context.Background(), comment)
+		if err != nil || !spam {
+			t.Errorf("Expected client %d to replay spam verdict, but got '%v' and error '%v'", i, spam, err)
+		}
+	}
+}
+
+func TestCassetteRecordDoesNotModifyRequest(t *testing.T) {
+	cassette := &Cassette{mode: ModeRecord}
+	transport := &transportMock{
+		roundTripResp: &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewBufferString("valid")),
+		},
+	}
+	body := ioutil.NopCloser(strings.NewReader("key=deadbeef"))
+	req, _ := http.NewRequest(http.MethodPost, "https://deadbeef.rest.akismet.com/1.1/verify-key", body)
+
+	resp, err := cassette.Transport(transport).RoundTrip(req)
+	if err != nil {
+		t.Fatalf("got error recording request: '%v'", err)
+	}
+	if req.Body != body {
+		t.Errorf("Expected request body to be left untouched")
+	}
+	if resp.Request != req {
+		t.Errorf("Expected response to reference original request")
+	}
+}
@@ -0,0 +1,171 @@
+package akismet
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Checker checks whether comment is spam. Akismet client implements it, so it can be combined or replaced with other
+// checkers.
+type Checker interface {
+	CheckVerdict(ctx context.Context, c *Comment, opts ...CallOpt) (Verdict, error)
+}
+
+// CheckerFunc is an adapter allowing use of ordinary function as Checker.
+type CheckerFunc func(ctx context.Context, c *Comment, opts ...CallOpt) (Verdict, error)
+
+// CheckVerdict calls f(ctx, c, opts...).
+func (f CheckerFunc) CheckVerdict(ctx context.Context, c *Comment, opts ...CallOpt) (Verdict, error) {
+	return f(ctx, c, opts...)
+}
+
+// RulesChecker returns checker evaluating rules in order, verdict is undecided when none of them is decisive.
+func RulesChecker(rules ...Rule) Checker {
+	return CheckerFunc(func(_ context.Context, c *Comment, _ ...CallOpt) (Verdict, error) {
+		result := EvaluateRules(c, rules...)
+		return Verdict{
+			Spam:      result.Decision == RuleSpam,
+			Reason:    result.Reason,
+			Undecided: result.Decision == RuleUndecided,
+		}, nil
+	})
+}
+
+// FirstDecisive returns checker calling checkers in order until one of them gives decisive verdict, i.e. local rules
+// first and Akismet when they are undecided. Error of any checker ends the check, verdict is undecided when no checker
+// is decisive, undecided verdicts are never returned as ham.
+func FirstDecisive(checkers ...Checker) Checker {
+	return CheckerFunc(func(ctx context.Context, c *Comment, opts ...CallOpt) (Verdict, error) {
+		for _, checker := range checkers {
+			verdict, err := checker.CheckVerdict(ctx, c, opts...)
+			if err != nil || !verdict.Undecided {
+				return verdict, err
+			}
+		}
+		return Verdict{Undecided: true, Reason: "no decisive checker"}, nil
+	})
+}
+
+// MajorityVote returns checker calling all checkers concurrently, comment is spam when more than half of decisive
+// verdicts say so. Checkers that failed or are undecided don't vote, undecided ones are counted in reason. Verdict is
+// undecided on a tie, including when there are no decisive votes at all. Error is returned only when all checkers
+// failed.
+func MajorityVote(checkers ...Checker) Checker {
+	return CheckerFunc(func(ctx context.Context, c *Comment, opts ...CallOpt) (Verdict, error) {
+		results := checkAll(ctx, c, opts, checkers)
+		spam, ham, undecided := 0, 0, 0
+		for _, result := range results {
+			switch {
+			case result.err != nil:
+				continue
+			case result.verdict.Undecided:
+				undecided++
+			case result.verdict.Spam:
+				spam++
+			default:
+				ham++
+			}
+		}
+		if err := allFailed(results); err != nil {
+			return Verdict{Spam: true}, err
+		}
+		reason := fmt.Sprintf("%d of %d votes for spam", spam, spam+ham)
+		if undecided > 0 {
+			reason += fmt.Sprintf(", %d undecided", undecided)
+		}
+		if spam == ham {
+			return Verdict{Undecided: true, Reason: reason}, nil
+		}
+		return Verdict{
+			Spam:   spam > ham,
+			Reason: reason,
+			Score:  float64(spam) / float64(spam+ham),
+		}, nil
+	})
+}
+
+// WeightedChecker is checker with weight of its score.
+type WeightedChecker struct {
+	Checker Checker
+	Weight  float64
+}
+
+// WeightedScore returns checker calling all checkers concurrently and computing weighted average of their scores (see
+// Verdict.SpamScore), comment is spam when it reaches threshold. Checkers that failed or are undecided are skipped, so
+// undecided verdicts don't lower the score, and verdict is undecided when none of them is left. Error is returned only
+// when all checkers failed.
+func WeightedScore(threshold float64, weighted ...WeightedChecker) Checker {
+	checkers := make([]Checker, len(weighted))
+	for i, w := range weighted {
+		checkers[i] = w.Checker
+	}
+	return CheckerFunc(func(ctx context.Context, c *Comment, opts ...CallOpt) (Verdict, error) {
+		results := checkAll(ctx, c, opts, checkers)
+		var sum, weights float64
+		undecided := 0
+		for i, result := range results {
+			if result.err != nil {
+				continue
+			}
+			if result.verdict.Undecided {
+				undecided++
+				continue
+			}
+			sum += weighted[i].Weight * result.verdict.SpamScore()
+			weights += weighted[i].Weight
+		}
+		if err := allFailed(results); err != nil {
+			return Verdict{Spam: true}, err
+		}
+		if weights == 0 {
+			return Verdict{Undecided: true, Reason: fmt.Sprintf("no decisive checkers, %d undecided", undecided)}, nil
+		}
+		score := sum / weights
+		reason := fmt.Sprintf("weighted score %.2f, threshold %.2f", score, threshold)
+		if undecided > 0 {
+			reason += fmt.Sprintf(", %d undecided", undecided)
+		}
+		return Verdict{
+			Spam:   score >= threshold,
+			Reason: reason,
+			Score:  score,
+		}, nil
+	})
+}
+
+type checkResult struct {
+	verdict Verdict
+	err     error
+}
+
+// checkAll calls all checkers concurrently, results are in order of checkers.
+func checkAll(ctx context.Context, c *Comment, opts []CallOpt, checkers []Checker) []checkResult {
+	results := make([]checkResult, len(checkers))
+	wg := &sync.WaitGroup{}
+	wg.Add(len(checkers))
+	for i, checker := range checkers {
+		go func(i int, checker Checker) {
+			defer wg.Done()
+			verdict, err := checker.CheckVerdict(ctx, c, opts...)
+			results[i] = checkResult{verdict: verdict, err: err}
+		}(i, checker)
+	}
+	wg.Wait()
+	return results
+}
+
+// allFailed returns error of the first checker when all of them failed.
+func allFailed(results []checkResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+	for _, result := range results {
+		if result.err == nil {
+			return nil
+		}
+	}
+	return errors.Wrap(results[0].err, "all checkers failed")
+}
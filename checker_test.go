@@ -0,0 +1,129 @@
+package akismet
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestCombinators(t *testing.T) {
+	checkErr := errors.New("check error")
+	undecided := staticChecker(Verdict{Undecided: true}, nil)
+	spam := staticChecker(Verdict{Spam: true, GUID: "spam"}, nil)
+	ham := staticChecker(Verdict{GUID: "ham"}, nil)
+	failing := staticChecker(Verdict{Spam: true}, checkErr)
+	scored := func(score float64) Checker { return staticChecker(Verdict{Score: score}, nil) }
+
+	spamRule := func(*Comment) RuleResult { return RuleResult{Decision: RuleSpam, Reason: "spam rule"} }
+	undecidedRule := func(*Comment) RuleResult { return RuleResult{} }
+
+	tests := []struct {
+		name       string
+		checker    Checker
+		expVerdict Verdict
+		expErr     string
+	}{{
+		name:       "rules checker decisive",
+		checker:    RulesChecker(undecidedRule, spamRule),
+		expVerdict: Verdict{Spam: true, Reason: "spam rule"},
+	}, {
+		name:       "rules checker undecided",
+		checker:    RulesChecker(undecidedRule),
+		expVerdict: Verdict{Undecided: true},
+	}, {
+		name:       "first decisive skips undecided",
+		checker:    FirstDecisive(undecided, RulesChecker(undecidedRule), ham, spam),
+		expVerdict: Verdict{GUID: "ham"},
+	}, {
+		name:       "first decisive stops on error",
+		checker:    FirstDecisive(undecided, failing, ham),
+		expVerdict: Verdict{Spam: true},
+		expErr:     "check error",
+	}, {
+		name:       "first decisive all undecided",
+		checker:    FirstDecisive(undecided, undecided),
+		expVerdict: Verdict{Undecided: true, Reason: "no decisive checker"},
+	}, {
+		name:       "first decisive doesn't return undecided spam",
+		checker:    FirstDecisive(staticChecker(Verdict{Undecided: true, Spam: true}, nil), ham),
+		expVerdict: Verdict{GUID: "ham"},
+	}, {
+		name:       "majority vote spam",
+		checker:    MajorityVote(spam, spam, ham, undecided, failing),
+		expVerdict: Verdict{Spam: true, Reason: "2 of 3 votes for spam, 1 undecided", Score: 2.0 / 3},
+	}, {
+		name:       "majority vote ham",
+		checker:    MajorityVote(spam, ham, ham),
+		expVerdict: Verdict{Reason: "1 of 3 votes for spam", Score: 1.0 / 3},
+	}, {
+		name:       "majority vote tie",
+		checker:    MajorityVote(spam, ham, undecided),
+		expVerdict: Verdict{Undecided: true, Reason: "1 of 2 votes for spam, 1 undecided"},
+	}, {
+		name:       "majority vote all undecided",
+		checker:    MajorityVote(undecided, undecided, failing),
+		expVerdict: Verdict{Undecided: true, Reason: "0 of 0 votes for spam, 2 undecided"},
+	}, {
+		name:       "majority vote all failed",
+		checker:    MajorityVote(failing, failing),
+		expVerdict: Verdict{Spam: true},
+		expErr:     "all checkers failed: check error",
+	}, {
+		name: "weighted score spam",
+		checker: WeightedScore(0.5,
+			WeightedChecker{Checker: spam, Weight: 3},
+			WeightedChecker{Checker: ham, Weight: 1},
+			WeightedChecker{Checker: failing, Weight: 10},
+		),
+		expVerdict: Verdict{Spam: true, Reason: "weighted score 0.75, threshold 0.50", Score: 0.75},
+	}, {
+		name: "weighted score uses checker's score",
+		checker: WeightedScore(0.5,
+			WeightedChecker{Checker: scored(0.2), Weight: 1},
+			WeightedChecker{Checker: scored(0.6), Weight: 1},
+			WeightedChecker{Checker: undecided, Weight: 5},
+		),
+		expVerdict: Verdict{Reason: "weighted score 0.40, threshold 0.50, 1 undecided", Score: 0.4},
+	}, {
+		name:       "weighted score undecided",
+		checker:    WeightedScore(0.5, WeightedChecker{Checker: undecided, Weight: 1}),
+		expVerdict: Verdict{Undecided: true, Reason: "no decisive checkers, 1 undecided"},
+	}, {
+		name:       "weighted score all failed",
+		checker:    WeightedScore(0.5, WeightedChecker{Checker: failing, Weight: 1}),
+		expVerdict: Verdict{Spam: true},
+		expErr:     "all checkers failed: check error",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict, err := tt.checker.CheckVerdict(context.Background(), &Comment{})
+			if !reflect.DeepEqual(tt.expVerdict, verdict) {
+				t.Errorf("Expected verdict to be '%#v', but got '%#v'", tt.expVerdict, verdict)
+			}
+			if (err == nil && tt.expErr != "") || (err != nil && err.Error() != tt.expErr) {
+				t.Errorf("Expected error to be '%s', but got '%v'", tt.expErr, err)
+			}
+		})
+	}
+}
+
+func TestVerdictSpamScore(t *testing.T) {
+	tests := []struct {
+		verdict  Verdict
+		expected float64
+	}{
+		{Verdict{}, 0},
+		{Verdict{Spam: true}, 1},
+		{Verdict{Spam: true, Score: 0.8}, 0.8},
+		{Verdict{Score: 0.3}, 0.3},
+		{Verdict{Undecided: true}, 0.5},
+		{Verdict{Undecided: true, Spam: true, Score: 0.9}, 0.5},
+	}
+	for _, tt := range tests {
+		if score := tt.verdict.SpamScore(); score != tt.expected {
+			t.Errorf("Expected score of '%#v' to be %v, but got %v", tt.verdict, tt.expected, score)
+		}
+	}
+}
@@ -3,13 +3,15 @@ package akismet
 import (
 	"context"
 	stderr "errors"
-	"fmt"
 	"net/http"
 	"net/url"
 
 	"github.com/pkg/errors"
 )
 
+// Version is version of this library, sent in User-Agent header.
+const Version = "1.0.0"
+
 const (
 	commentCheckEndpoint    = "comment-check"
 	keyVerificationEndpoint = "verify-key"
@@ -17,6 +19,11 @@ const (
 	submitHamEndpoint       = "submit-ham"
 
 	spamHamResponse = "Thanks for making the web a better place."
+
+	// RecheckReasonEdit is recheck reason used when comment was edited.
+	RecheckReasonEdit = "edit"
+	// RecheckReasonQueue is recheck reason used when comment is rechecked after being held in queue.
+	RecheckReasonQueue = "recheck_queue"
 )
 
 var (
@@ -36,6 +43,22 @@ type akismetClient struct {
 	blogUrl    string
 	akismetUrl string
 	httpClient *http.Client
+
+	maxResponseSize int64
+	userAgent       string
+	headers         http.Header
+	interceptors    []Interceptor
+	auditSink       AuditSink
+	auditError      func(error)
+	normalize       *NormalizeConfig
+
+	redaction       RedactionPolicy
+	redactionReport func(RedactionReport)
+	testMode        *TestMode
+	preFilter       []Rule
+	cassette        *Cassette
+
+	languageEnricher *LanguageEnricher
 }
 
 // NewAkismet returns new instance of Akismet client with optional error.
@@ -59,86 +82,204 @@ func NewAkismet(key, blogUrl string, optFns ...OptFn) (*akismetClient, error) {
 	for _, fn := range optFns {
 		fn(client)
 	}
+	if client.cassette != nil {
+		httpClient := client.copyHttpClient()
+		httpClient.Transport = client.cassette.Transport(httpClient.Transport)
+		client.httpClient = httpClient
+	}
+	if client.testMode != nil {
+		if err := client.testMode.checkKey(key); err != nil {
+			return nil, err
+		}
+	}
 
 	return client, nil
 }
 
 // Check calls Akismet's check comment endpoint and return true or false along with error that indicates error during process.
-func (a *akismetClient) Check(ctx context.Context, c *Comment) (bool, error) {
+func (a *akismetClient) Check(ctx context.Context, c *Comment, opts ...CallOpt) (bool, error) {
+	verdict, err := a.CheckVerdict(ctx, c, opts...)
+	return verdict.Spam, err
+}
+
+// CheckVerdict calls Akismet's check comment endpoint and return verdict, with additional information sent by Akismet in
+// response headers, along with error that indicates error during process. When pre-filter rules are set, and one of
+// them is decisive, verdict is returned without calling Akismet.
+func (a *akismetClient) CheckVerdict(ctx context.Context, c *Comment, opts ...CallOpt) (Verdict, error) {
+	co, err := newCallOpts(opts)
+	if err != nil {
+		err = errors.Wrap(err, "error applying call options")
+		a.audit(ctx, AuditCheck, c, co, Verdict{}, err)
+		return Verdict{}, err
+	}
+	verdict, err := a.checkComment(ctx, c, co)
+	a.audit(ctx, AuditCheck, c, co, verdict, err)
+	return verdict, err
+}
+
+// checkComment validates comment, evaluates pre-filter rules and calls Akismet when none of them is decisive.
+func (a *akismetClient) checkComment(ctx context.Context, c *Comment, co callOpts) (Verdict, error) {
 	if err := c.Validate(); err != nil {
-		return false, errors.Wrap(err, "error validating comment struct")
+		return Verdict{}, errors.Wrap(err, "error validating comment struct")
 	}
-	commentCheckUrl := fmt.Sprintf(a.akismetUrl, a.key, commentCheckEndpoint)
-	payload := c.toValues()
-	respBody, err := a.post(ctx, commentCheckUrl, payload)
+	if result := EvaluateRules(c, a.preFilter...); result.Decision != RuleUndecided {
+		return Verdict{Spam: result.Decision == RuleSpam, Reason: result.Reason}, nil
+	}
+	payload, err := a.commentPayload(c, &co)
 	if err != nil {
-		return true, errors.Wrap(err, "error during comment check request")
+		return Verdict{}, err
 	}
+	ctx, cancel := co.context(ctx)
+	defer cancel()
+	resp, err := a.call(ctx, commentCheckEndpoint, payload, co, parseVerdict)
+	verdict := Verdict{Spam: true}
+	if resp != nil {
+		if v, ok := resp.Result.(Verdict); ok {
+			verdict = v
+		}
+	}
+	return verdict, err
+}
 
-	if respBody == "true" {
-		return true, nil
+// parseVerdict parses comment check response, unusual response is treated as spam.
+func parseVerdict(body string, header http.Header) (interface{}, error) {
+	verdict := newVerdict(header)
+	if body == "true" {
+		verdict.Spam = true
+		return verdict, nil
 	}
-	if respBody == "false" {
-		return false, nil
+	if body == "false" {
+		return verdict, nil
 	}
 
-	return true, errors.Wrapf(ErrUnusualResponse, "got response: '%s'", respBody)
+	verdict.Spam = true
+	return verdict, errors.Wrapf(ErrUnusualResponse, "got response: '%s'", body)
+}
+
+// Recheck checks comment again, sending the reason of recheck, i.e. RecheckReasonEdit when comment was edited.
+func (a *akismetClient) Recheck(ctx context.Context, c *Comment, reason string, opts ...CallOpt) (Verdict, error) {
+	recheck := *c
+	recheck.RecheckReason = reason
+	return a.CheckVerdict(ctx, &recheck, opts...)
 }
 
 // Verify call Akismet's key verification endpoint and return true or false along with error that indicates error during process.
 func (a *akismetClient) Verify(ctx context.Context) (bool, error) {
 	payload := &url.Values{}
 	payload.Add("key", a.key)
-	verifyUrl := fmt.Sprintf(a.akismetUrl, a.key, keyVerificationEndpoint)
-	respBody, err := a.post(ctx, verifyUrl, payload)
-	if err != nil {
-		return false, errors.Wrap(err, "error during comment check request")
+	resp, err := a.call(ctx, keyVerificationEndpoint, payload, callOpts{}, parseVerify)
+	if resp != nil {
+		if valid, ok := resp.Result.(bool); ok {
+			return valid, err
+		}
 	}
+	return false, err
+}
 
-	if respBody == "valid" {
+// parseVerify parses key verification response.
+func parseVerify(body string, _ http.Header) (interface{}, error) {
+	if body == "valid" {
 		return true, nil
 	}
-	if respBody == "invalid" {
+	if body == "invalid" {
 		return false, nil
 	}
 
-	return false, errors.Wrapf(ErrUnusualResponse, "got response: '%s'", respBody)
+	return false, errors.Wrapf(ErrUnusualResponse, "got response: '%s'", body)
 }
 
 // SubmitSpam calls Akismet's submit spam endpoint and error that indicates error during process.
-func (a *akismetClient) SubmitSpam(ctx context.Context, c *Comment) error {
+func (a *akismetClient) SubmitSpam(ctx context.Context, c *Comment, opts ...CallOpt) error {
+	return a.submit(ctx, submitSpamEndpoint, c, opts)
+}
+
+// SubmitHam calls Akismet's submit ham endpoint and error that indicates error during process.
+func (a *akismetClient) SubmitHam(ctx context.Context, c *Comment, opts ...CallOpt) error {
+	return a.submit(ctx, submitHamEndpoint, c, opts)
+}
+
+func (a *akismetClient) submit(ctx context.Context, endpoint string, c *Comment, opts []CallOpt) error {
+	co, err := newCallOpts(opts)
+	if err != nil {
+		err = errors.Wrap(err, "error applying call options")
+	} else {
+		err = a.submitComment(ctx, endpoint, c, co)
+	}
+	action := AuditSubmitHam
+	if endpoint == submitSpamEndpoint {
+		action = AuditSubmitSpam
+	}
+	a.audit(ctx, action, c, co, Verdict{Spam: endpoint == submitSpamEndpoint, GUID: co.guid}, err)
+	return err
+}
+
+// submitComment validates comment and sends it to given submit endpoint.
+func (a *akismetClient) submitComment(ctx context.Context, endpoint string, c *Comment, co callOpts) error {
 	if err := c.Validate(); err != nil {
 		return errors.Wrap(err, "error validating comment struct")
 	}
-	payload := c.toValues()
-	verifyUrl := fmt.Sprintf(a.akismetUrl, a.key, submitSpamEndpoint)
-	respBody, err := a.post(ctx, verifyUrl, payload)
+	payload, err := a.commentPayload(c, &co)
 	if err != nil {
-		return errors.Wrap(err, "error during comment check request")
+		return err
 	}
+	ctx, cancel := co.context(ctx)
+	defer cancel()
+	_, err = a.call(ctx, endpoint, payload, co, parseSubmit)
+	return err
+}
 
-	if respBody == spamHamResponse {
-		return nil
+// parseSubmit parses submit spam and submit ham response.
+func parseSubmit(body string, _ http.Header) (interface{}, error) {
+	if body == spamHamResponse {
+		return nil, nil
 	}
 
-	return errors.Wrapf(ErrUnusualResponse, "got response: '%s'", respBody)
+	return nil, errors.Wrapf(ErrUnusualResponse, "got response: '%s'", body)
 }
 
-// SubmitSpam calls Akismet's submit spam endpoint and error that indicates error during process.
-func (a *akismetClient) SubmitHam(ctx context.Context, c *Comment) error {
-	if err := c.Validate(); err != nil {
-		return errors.Wrap(err, "error validating comment struct")
+// commentPayload serializes normalized and language enriched copy of comment into parameters, adding parameters from
+// call options, marking it as test one in test mode and applying configured redaction policy. Names of redacted
+// parameters are stored in call options, so they can be reported once the request is sent. Comment itself is left as
+// it was, as rules, audit and fingerprint refer to the original.
+func (a *akismetClient) commentPayload(c *Comment, co *callOpts) (*url.Values, error) {
+	c, err := a.normalized(c)
+	if err != nil {
+		return nil, err
+	}
+	if a.languageEnricher != nil {
+		enriched := *c
+		if err := a.languageEnricher.Enrich(&enriched, co.acceptLanguage); err != nil {
+			return nil, errors.Wrap(err, "error enriching comment language")
+		}
+		c = &enriched
 	}
 	payload := c.toValues()
-	verifyUrl := fmt.Sprintf(a.akismetUrl, a.key, submitHamEndpoint)
-	respBody, err := a.post(ctx, verifyUrl, payload)
-	if err != nil {
-		return errors.Wrap(err, "error during comment check request")
+	for name, values := range co.params {
+		(*payload)[name] = values
 	}
-
-	if respBody == spamHamResponse {
-		return nil
+	if a.redaction != nil {
+		redacted, err := a.redaction.apply(payload)
+		if err != nil {
+			return nil, errors.Wrap(err, "error redacting comment")
+		}
+		co.redacted = redacted
+	}
+	// test flag is set after redaction, so that policy can't remove it
+	if co.isTest || a.testMode != nil {
+		payload.Set("is_test", "1")
 	}
+	return payload, nil
+}
 
-	return errors.Wrapf(ErrUnusualResponse, "got response: '%s'", respBody)
+// normalized returns normalized copy of comment, when normalization is configured.
+func (a *akismetClient) normalized(c *Comment) (*Comment, error) {
+	if a.normalize == nil {
+		return c, nil
+	}
+	normalized := *c
+	normalized.Context = append([]string(nil), c.Context...)
+	if err := normalized.Normalize(*a.normalize); err != nil {
+		return nil, errors.Wrap(err, "error normalizing comment")
+	}
+	return &normalized, nil
 }
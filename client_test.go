@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -44,11 +46,23 @@ func TestNewAkismet(t *testing.T) {
 	hasClient := func(expClient *akismetClient) check {
 		return func(client *akismetClient, _ error, t *testing.T) {
 			t.Helper()
+			if client != nil {
+				// default http client is checked separately, as transport's dial func cannot be compared
+				withoutHttpClient := *client
+				withoutHttpClient.httpClient = nil
+				client = &withoutHttpClient
+			}
 			if !reflect.DeepEqual(expClient, client) {
 				t.Errorf("Expected Akismet client to be '%v', but got '%v'", expClient, client)
 			}
 		}
 	}
+	hasDefaultHttpClient := func(client *akismetClient, _ error, t *testing.T) {
+		t.Helper()
+		if client.httpClient == nil || client.httpClient.Timeout != defaultTimeout {
+			t.Errorf("Expected default http client with '%v' timeout, but got '%v'", defaultTimeout, client.httpClient)
+		}
+	}
 
 	tests := []struct {
 		name    string
@@ -89,8 +103,9 @@ func TestNewAkismet(t *testing.T) {
 				key:        "deadbeef",
 				blogUrl:    "http://some-blog.com",
 				akismetUrl: "https://%s.rest.akismet.com/1.1/%s",
-				httpClient: &http.Client{},
+				userAgent:  "AkismetGoClient/" + Version,
 			}),
+			hasDefaultHttpClient,
 		),
 	}}
 	for _, tt := range tests {
@@ -205,6 +220,35 @@ func TestAkismetCheck(t *testing.T) {
 			hasResult(true),
 			hasPayload("blog=http%3A%2F%2Fsome-blog.com&blog_charset=11&blog_lang=10&comment_author=6&comment_author_email=7&comment_author_url=8&comment_content=9&comment_date_gmt=2019-06-30T13%3A43%3A12Z&comment_post_modified_gmt=2019-06-30T14%3A43%3A12Z&comment_type=5&is_test=13&permalink=4&recheck_reason=14&referrer=3&user_agent=2&user_ip=1&user_role=12"),
 		),
+	}, {
+		name: "success with honeypot serialization",
+		comment: &Comment{
+			UserIP:            "0.0.0.0",
+			UserAgent:         "Mozilla/6.16",
+			HoneypotFieldName: "website_confirm",
+			HoneypotValue:     "http://spam.com",
+		},
+		responseBody:       "true",
+		responseStatusCode: 200,
+		checks: checks(
+			hasNoError,
+			hasResult(true),
+			hasPayload("blog=http%3A%2F%2Fsome-blog.com&honeypot_field_name=website_confirm&user_agent=Mozilla%2F6.16&user_ip=0.0.0.0&website_confirm=http%3A%2F%2Fspam.com"),
+		),
+	}, {
+		name: "success with context serialization",
+		comment: &Comment{
+			UserIP:    "0.0.0.0",
+			UserAgent: "Mozilla/6.16",
+			Context:   []string{"Go", "Akismet"},
+		},
+		responseBody:       "false",
+		responseStatusCode: 200,
+		checks: checks(
+			hasNoError,
+			hasResult(false),
+			hasPayload("blog=http%3A%2F%2Fsome-blog.com&comment_context%5B%5D=Go&comment_context%5B%5D=Akismet&user_agent=Mozilla%2F6.16&user_ip=0.0.0.0"),
+		),
 	}, {
 		name:               "error when status code is not OK",
 		comment:            validComment,
@@ -263,6 +307,91 @@ func TestAkismetCheck(t *testing.T) {
 	}
 }
 
+func TestAkismetCheckVerdict(t *testing.T) {
+	type check func(result Verdict, err error, t *testing.T)
+	checks := func(cs ...check) []check { return cs }
+
+	hasCauseError := func(exp error) check {
+		return func(_ Verdict, err error, t *testing.T) {
+			t.Helper()
+			if errors.Cause(err) != exp {
+				t.Errorf("Expected error cause to be '%v', but got '%v'", exp, err)
+			}
+		}
+	}
+	hasNoError := func(_ Verdict, err error, t *testing.T) {
+		t.Helper()
+		if err != nil {
+			t.Errorf("Expected error to be nil, but got '%v'", err)
+		}
+	}
+	hasVerdict := func(exp Verdict) check {
+		return func(result Verdict, _ error, t *testing.T) {
+			t.Helper()
+			if !reflect.DeepEqual(exp, result) {
+				t.Errorf("Expected verdict to be '%+v', but got '%+v'", exp, result)
+			}
+		}
+	}
+
+	tests := []struct {
+		name           string
+		responseBody   string
+		responseHeader map[string]string
+		checks         []check
+	}{{
+		name:         "success with additional headers",
+		responseBody: "true",
+		responseHeader: map[string]string{
+			"X-akismet-guid":    "d4a3c8a4e0f1",
+			"X-akismet-pro-tip": "discard",
+		},
+		checks: checks(
+			hasNoError,
+			hasVerdict(Verdict{Spam: true, GUID: "d4a3c8a4e0f1", ProTip: "discard"}),
+		),
+	}, {
+		name:         "success without additional headers",
+		responseBody: "false",
+		checks: checks(
+			hasNoError,
+			hasVerdict(Verdict{}),
+		),
+	}, {
+		name:         "error with debug help when got unusual response",
+		responseBody: "invalid",
+		responseHeader: map[string]string{
+			"X-akismet-debug-help": "Empty \"blog\" value",
+		},
+		checks: checks(
+			hasCauseError(ErrUnusualResponse),
+			hasVerdict(Verdict{Spam: true, DebugHelp: "Empty \"blog\" value"}),
+		),
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tt.responseHeader {
+					w.Header().Set(k, v)
+				}
+				fmt.Fprint(w, tt.responseBody)
+			}))
+			defer ts.Close()
+
+			cli := &akismetClient{
+				key:        "deadbeef",
+				blogUrl:    "http://some-blog.com",
+				httpClient: &http.Client{},
+				akismetUrl: ts.URL + "/%s/%s",
+			}
+			result, err := cli.CheckVerdict(context.Background(), &Comment{UserIP: "0.0.0.0", UserAgent: "Mozilla/6.16"})
+			for _, ch := range tt.checks {
+				ch(result, err, t)
+			}
+		})
+	}
+}
+
 func TestAkismetVerify(t *testing.T) {
 	type check func(result bool, err error, payload []byte, t *testing.T)
 	checks := func(cs ...check) []check { return cs }
@@ -652,11 +781,22 @@ func TestAkismetMaliciousResponses(t *testing.T) {
 		}
 	}
 
+	hasStatusError := func(exp *StatusError) check {
+		return func(err error, t *testing.T) {
+			t.Helper()
+			statusErr, ok := err.(*StatusError)
+			if !ok || !reflect.DeepEqual(exp, statusErr) {
+				t.Errorf("Expected error to be '%#v', but got '%#v'", exp, err)
+			}
+		}
+	}
+
 	tests := []struct {
-		name       string
-		httpClient *http.Client
-		url        string
-		checks     []check
+		name            string
+		httpClient      *http.Client
+		url             string
+		maxResponseSize int64
+		checks          []check
 	}{{
 		name: "success when 'valid' as a response",
 		httpClient: &http.Client{
@@ -711,19 +851,175 @@ func TestAkismetMaliciousResponses(t *testing.T) {
 		},
 		checks: checks(
 			hasCauseError(ErrNonOKStatusCode),
-			hasErrorMsg("got status code 418: akismet API returned non 200 status code"),
+			hasErrorMsg(`got status code 418, body: "ok": akismet API returned non 200 status code`),
+		),
+	}, {
+		name: "error with body snippet and headers when got status code other than OK",
+		httpClient: &http.Client{
+			Transport: &transportMock{
+				roundTripResp: &http.Response{
+					StatusCode: 500,
+					Header:     http.Header{"X-Akismet-Debug-Help": []string{"try later"}},
+					Body:       ioutil.NopCloser(bytes.NewBufferString(strings.Repeat("a", 1024))),
+				},
+			},
+		},
+		checks: checks(
+			hasCauseError(ErrNonOKStatusCode),
+			hasStatusError(&StatusError{
+				StatusCode: 500,
+				Body:       strings.Repeat("a", 512),
+				Header:     http.Header{"X-Akismet-Debug-Help": []string{"try later"}},
+			}),
+		),
+	}, {
+		name: "error when response is too large",
+		httpClient: &http.Client{
+			Transport: &transportMock{
+				roundTripResp: &http.Response{
+					StatusCode: 200,
+					Body:       ioutil.NopCloser(bytes.NewBufferString("1234567")),
+				},
+			},
+		},
+		maxResponseSize: 4,
+		checks: checks(
+			hasCauseError(ErrResponseTooLarge),
+			hasErrorMsg("limit is 4 bytes: akismet API response is too large"),
+		),
+	}, {
+		name: "success when response has maximum size",
+		httpClient: &http.Client{
+			Transport: &transportMock{
+				roundTripResp: &http.Response{
+					StatusCode: 200,
+					Body:       ioutil.NopCloser(bytes.NewBufferString("1234")),
+				},
+			},
+		},
+		maxResponseSize: 4,
+		checks: checks(
+			hasNoError,
 		),
 	}}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			cli := &akismetClient{
-				blogUrl:    "http://some-blog.com",
-				httpClient: tt.httpClient,
+				blogUrl:         "http://some-blog.com",
+				httpClient:      tt.httpClient,
+				maxResponseSize: tt.maxResponseSize,
 			}
-			_, err := cli.post(context.Background(), tt.url, &url.Values{})
+			_, _, err := cli.post(context.Background(), tt.url, url.Values{}, nil)
 			for _, ch := range tt.checks {
 				ch(err, t)
 			}
 		})
 	}
 }
+
+type trackingBody struct {
+	r      io.Reader
+	closed chan struct{}
+}
+
+func newTrackingBody(r io.Reader) *trackingBody {
+	return &trackingBody{r: r, closed: make(chan struct{})}
+}
+
+func (b *trackingBody) Read(p []byte) (int, error) {
+	select {
+	case <-b.closed:
+		return 0, errors.New("read on closed body")
+	default:
+	}
+	return b.r.Read(p)
+}
+
+func (b *trackingBody) Close() error {
+	select {
+	case <-b.closed:
+	default:
+		close(b.closed)
+	}
+	return nil
+}
+
+func (b *trackingBody) isClosed() bool {
+	select {
+	case <-b.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestPostBodyHandling(t *testing.T) {
+	tests := []struct {
+		name            string
+		statusCode      int
+		body            string
+		maxResponseSize int64
+	}{{
+		name:       "body drained and closed on success",
+		statusCode: 200,
+		body:       "true",
+	}, {
+		name:       "body drained and closed on non OK status",
+		statusCode: 503,
+		body:       strings.Repeat("a", 4096),
+	}, {
+		name:            "body drained and closed when response is too large",
+		statusCode:      200,
+		body:            strings.Repeat("a", 4096),
+		maxResponseSize: 16,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := strings.NewReader(tt.body)
+			body := newTrackingBody(reader)
+			cli := &akismetClient{
+				blogUrl: "http://some-blog.com",
+				httpClient: &http.Client{Transport: &transportMock{
+					roundTripResp: &http.Response{StatusCode: tt.statusCode, Body: body},
+				}},
+				maxResponseSize: tt.maxResponseSize,
+			}
+			_, _, _ = cli.post(context.Background(), "http://example.com", url.Values{}, nil)
+			if !body.isClosed() {
+				t.Error("Expected body to be closed")
+			}
+			if reader.Len() != 0 {
+				t.Errorf("Expected body to be drained, but %d bytes left", reader.Len())
+			}
+		})
+	}
+}
+
+type blockingBody struct {
+	*trackingBody
+}
+
+func (b blockingBody) Read([]byte) (int, error) {
+	<-b.closed
+	return 0, errors.New("read on closed body")
+}
+
+func TestPostContextCancelledMidRead(t *testing.T) {
+	body := blockingBody{newTrackingBody(nil)}
+	cli := &akismetClient{
+		blogUrl: "http://some-blog.com",
+		httpClient: &http.Client{Transport: &transportMock{
+			roundTripResp: &http.Response{StatusCode: 200, Body: body},
+		}},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, _, err := cli.post(ctx, "http://example.com", url.Values{}, nil)
+	if errors.Cause(err) != context.DeadlineExceeded {
+		t.Errorf("Expected error cause to be '%v', but got '%v'", context.DeadlineExceeded, err)
+	}
+	if !body.isClosed() {
+		t.Error("Expected body to be closed")
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/Alkemic/akismet"
+)
+
+func bulkCheck(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("bulk-check", flag.ExitOnError)
+	key, blogUrl := clientFlags(fs)
+	in := fs.String("in", "", "Input JSONL file with comments")
+	out := fs.String("out", "", "Output JSONL file for verdicts, appended to when resuming")
+	checkpoint := fs.String("checkpoint", "", "Checkpoint file, allows resuming interrupted run")
+	concurrency := fs.Int("concurrency", 4, "Number of comments checked at the same time")
+	rateLimit := fs.Float64("rate", 10, "Maximum number of requests per second, 0 means no limit")
+	fs.Parse(args)
+	requireFlags(fs, map[string]*string{"key": key, "blog-url": blogUrl})
+
+	client, err := akismet.NewAkismet(*key, *blogUrl, akismet.WithUserAgent("akismet-cli", akismet.Version))
+	if err != nil {
+		log.Fatalf("error creating client instance: %v", err)
+	}
+
+	input, err := os.Open(*in)
+	if err != nil {
+		log.Fatalf("error opening input: %v", err)
+	}
+	defer input.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if *checkpoint != "" {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	output, err := os.OpenFile(*out, flags, 0644)
+	if err != nil {
+		log.Fatalf("error opening output: %v", err)
+	}
+	defer output.Close()
+
+	err = client.BulkCheck(ctx, input, output, akismet.BulkOptions{
+		Concurrency: *concurrency,
+		RateLimit:   *rateLimit,
+		Checkpoint:  *checkpoint,
+	})
+	if err != nil {
+		log.Fatalf("got error: %v", err)
+	}
+}
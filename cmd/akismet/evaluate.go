@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/Alkemic/akismet"
+)
+
+func evaluate(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("evaluate", flag.ExitOnError)
+	key, blogUrl := clientFlags(fs)
+	in := fs.String("in", "", "Input file with labelled comments")
+	format := fs.String("format", "jsonl", "Input format, jsonl or csv (with spam column)")
+	cassette := fs.String("cassette", "", "Cassette file to replay instead of calling Akismet")
+	concurrency := fs.Int("concurrency", 4, "Number of comments checked at the same time")
+	rateLimit := fs.Float64("rate", 10, "Maximum number of requests per second, 0 means no limit")
+	misclassified := fs.Int("misclassified", 20, "Maximum number of misclassified examples in report, 0 means no limit")
+	asJSON := fs.Bool("json", false, "Write report as JSON")
+	fs.Parse(args)
+	requireFlags(fs, map[string]*string{"key": key, "blog-url": blogUrl})
+
+	optFns := []akismet.OptFn{akismet.WithUserAgent("akismet-cli", akismet.Version)}
+	if *cassette != "" {
+		c, err := akismet.NewCassette(*cassette, akismet.ModeReplay)
+		if err != nil {
+			log.Fatalf("error loading cassette: %v", err)
+		}
+		optFns = append(optFns, akismet.WithCassette(c))
+	}
+	client, err := akismet.NewAkismet(*key, *blogUrl, optFns...)
+	if err != nil {
+		log.Fatalf("error creating client instance: %v", err)
+	}
+
+	input, err := os.Open(*in)
+	if err != nil {
+		log.Fatalf("error opening input: %v", err)
+	}
+	defer input.Close()
+
+	var dataset akismet.LabelledReader
+	switch *format {
+	case "jsonl":
+		dataset = akismet.NewJSONLLabelledReader(input)
+	case "csv":
+		reader, err := akismet.NewCSVReader(input, akismet.CSVConfig{})
+		if err != nil {
+			log.Fatalf("error opening input: %v", err)
+		}
+		dataset = reader.Labelled()
+	default:
+		log.Fatalf("unknown format: %s", *format)
+	}
+
+	report, err := akismet.Evaluate(ctx, client, dataset, akismet.EvalOptions{
+		Concurrency:      *concurrency,
+		RateLimit:        *rateLimit,
+		MaxMisclassified: *misclassified,
+	})
+	if err != nil {
+		log.Printf("got error: %v", err)
+	}
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Fatalf("error writing report: %v", err)
+		}
+	} else {
+		writeReport(os.Stdout, report)
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+func writeReport(w io.Writer, report *akismet.EvalReport) {
+	writeMatrix(w, "all", report.Matrix)
+	types := make([]string, 0, len(report.ByType))
+	for commentType := range report.ByType {
+		types = append(types, commentType)
+	}
+	sort.Strings(types)
+	for _, commentType := range types {
+		name := commentType
+		if name == "" {
+			name = "(no type)"
+		}
+		writeMatrix(w, name, report.ByType[commentType])
+	}
+	fmt.Fprintf(w, "undecided: %d, errors: %d\n", report.Undecided, len(report.Errors))
+	for _, e := range report.Errors {
+		fmt.Fprintf(w, "  line %d: %s\n", e.Line, e.Error)
+	}
+	if len(report.Misclassified) > 0 {
+		fmt.Fprintln(w, "misclassified:")
+	}
+	for _, m := range report.Misclassified {
+		fmt.Fprintf(w, "  line %d: expected spam %v, got %v: %.80q\n", m.Line, m.Expected, m.Verdict.Spam, m.Comment.Content)
+	}
+}
+
+func writeMatrix(w io.Writer, name string, m akismet.ConfusionMatrix) {
+	fmt.Fprintf(w, "%s (%d comments)\n", name, m.Total())
+	fmt.Fprintf(w, "                 spam   ham\n")
+	fmt.Fprintf(w, "  labelled spam %5d %5d\n", m.TruePositives, m.FalseNegatives)
+	fmt.Fprintf(w, "  labelled ham  %5d %5d\n", m.FalsePositives, m.TrueNegatives)
+	fmt.Fprintf(w, "  precision %.3f, recall %.3f, accuracy %.3f\n", m.Precision(), m.Recall(), m.Accuracy())
+}
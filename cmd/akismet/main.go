@@ -0,0 +1,54 @@
+// Command akismet is a command line tool for batch operations on comments using Akismet API.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+)
+
+var commands = map[string]func(ctx context.Context, args []string){
+	"bulk-check": bulkCheck,
+	"evaluate":   evaluate,
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s <command> [flags]\n\ncommands:\n", os.Args[0])
+	fmt.Fprintln(os.Stderr, "  bulk-check\tchecks JSONL file of comments and writes verdicts as JSONL")
+	fmt.Fprintln(os.Stderr, "  evaluate\tchecks JSONL or CSV file of labelled comments and reports precision and recall")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	command(ctx, os.Args[2:])
+}
+
+// clientFlags registers flags needed to create Akismet client.
+func clientFlags(fs *flag.FlagSet) (key, blogUrl *string) {
+	key = fs.String("key", "", "Akismet API key")
+	blogUrl = fs.String("blog-url", "", "Blog URI (inc. scheme), required")
+	return key, blogUrl
+}
+
+// requireFlags exits with usage when any of given flags is empty.
+func requireFlags(fs *flag.FlagSet, values map[string]*string) {
+	for name, value := range values {
+		if *value == "" {
+			fmt.Fprintf(os.Stderr, "flag -%s is required\n", name)
+			fs.Usage()
+			os.Exit(2)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package akismet
+
+// CommentContext describes surroundings of the comment, which improves accuracy of the check.
+type CommentContext struct {
+	PostTitle     string
+	PostExcerpt   string
+	Tags          []string
+	ParentContent string
+}
+
+// Post is implemented by blog post models, so comment context can be filled from them.
+type Post interface {
+	Title() string
+	Excerpt() string
+	Tags() []string
+}
+
+// ContextFromPost returns context of comment written under given post, in reply to parent comment, which is nil for
+// top level comments.
+func ContextFromPost(post Post, parent *Comment) CommentContext {
+	cc := CommentContext{
+		PostTitle:   post.Title(),
+		PostExcerpt: post.Excerpt(),
+		Tags:        post.Tags(),
+	}
+	if parent != nil {
+		cc.ParentContent = parent.Content
+	}
+	return cc
+}
+
+// Values returns non empty context entries, sent to Akismet as repeated comment_context[] parameters.
+func (cc CommentContext) Values() []string {
+	all := append(append([]string{cc.PostTitle, cc.PostExcerpt}, cc.Tags...), cc.ParentContent)
+	values := []string{}
+	for _, value := range all {
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// SetContext sets context of the comment, replacing the previous one.
+func (c *Comment) SetContext(cc CommentContext) {
+	c.Context = cc.Values()
+}
@@ -0,0 +1,47 @@
+package akismet
+
+import (
+	"reflect"
+	"testing"
+)
+
+type postMock struct {
+	title   string
+	excerpt string
+	tags    []string
+}
+
+func (p postMock) Title() string   { return p.title }
+func (p postMock) Excerpt() string { return p.excerpt }
+func (p postMock) Tags() []string  { return p.tags }
+
+func TestCommentSetContext(t *testing.T) {
+	post := postMock{title: "Akismet client", excerpt: "A GO Akismet client", tags: []string{"go", "spam"}}
+
+	tests := []struct {
+		name     string
+		context  CommentContext
+		expected []string
+	}{{
+		name:     "empty context",
+		context:  CommentContext{},
+		expected: []string{},
+	}, {
+		name:     "context of top level comment",
+		context:  ContextFromPost(post, nil),
+		expected: []string{"Akismet client", "A GO Akismet client", "go", "spam"},
+	}, {
+		name:     "context of reply",
+		context:  ContextFromPost(postMock{title: "Akismet client"}, &Comment{Content: "Nice one!"}),
+		expected: []string{"Akismet client", "Nice one!"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comment := &Comment{Context: []string{"previous"}}
+			comment.SetContext(tt.context)
+			if !reflect.DeepEqual(tt.expected, comment.Context) {
+				t.Errorf("Expected context to be '%v', but got '%v'", tt.expected, comment.Context)
+			}
+		})
+	}
+}
@@ -0,0 +1,350 @@
+package akismet
+
+import (
+	"encoding/csv"
+	"io"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Names of CSV columns holding label and verdict.
+const (
+	CSVSpamColumn   = "spam"
+	CSVGUIDColumn   = "guid"
+	CSVProTipColumn = "pro_tip"
+	CSVReasonColumn = "reason"
+	CSVErrorColumn  = "error"
+	// CSVHoneypotValueColumn holds Comment.HoneypotValue, which isn't Akismet's parameter on its own.
+	CSVHoneypotValueColumn = "honeypot_value"
+)
+
+// csvContextSeparator separates comment context texts within single CSV cell.
+const csvContextSeparator = "\n"
+
+var csvVerdictColumns = []string{CSVSpamColumn, CSVGUIDColumn, CSVProTipColumn, CSVReasonColumn, CSVErrorColumn}
+
+// CSVMapping maps CSV column headers to Akismet's parameter names (see Comment struct tags) or verdict columns, i.e.
+// {"IP": "user_ip", "Is spam": "spam"}. Columns not present in mapping are matched by their header, unknown columns
+// are ignored.
+type CSVMapping map[string]string
+
+// CSVConfig configures CSVReader and CSVWriter.
+type CSVConfig struct {
+	Mapping CSVMapping
+	// DateLayout is layout of dates in CSV, defaults to time.RFC3339. Dates are converted to RFC3339, as expected by
+	// Validate, when reading, and back when writing.
+	DateLayout string
+	// Comma is field delimiter, defaults to ','.
+	Comma rune
+	// KeepFormulas disables escaping of cells starting with '=', '+', '-', '@', tab or carriage return, which
+	// spreadsheets evaluate as formulas. By default such cells are prefixed with "'" when writing.
+	KeepFormulas bool
+	// UnescapeFormulas removes prefix added by escaping when reading. It should be set only for files written by
+	// CSVWriter, as it would change texts like "'=)" coming from other sources.
+	UnescapeFormulas bool
+}
+
+// escape prefixes value with "'" when spreadsheet would evaluate it as formula.
+func (c CSVConfig) escape(value string) string {
+	if c.KeepFormulas || !isFormula(value) {
+		return value
+	}
+	return "'" + value
+}
+
+// unescape removes prefix added by escape, when it's enabled.
+func (c CSVConfig) unescape(value string) string {
+	if !c.UnescapeFormulas || !strings.HasPrefix(value, "'") || !isFormula(value[1:]) {
+		return value
+	}
+	return value[1:]
+}
+
+// isFormula returns true when value starts with formula character, or it's a formula escaped already, so that
+// escaping can be reverted.
+func isFormula(value string) bool {
+	if value == "" {
+		return false
+	}
+	switch value[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return true
+	case '\'':
+		return isFormula(value[1:])
+	}
+	return false
+}
+
+func (c CSVConfig) dateLayout() string {
+	if c.DateLayout == "" {
+		return time.RFC3339
+	}
+	return c.DateLayout
+}
+
+// column returns parameter name of given header.
+func (c CSVConfig) column(header string) string {
+	if name, ok := c.Mapping[header]; ok {
+		return name
+	}
+	return header
+}
+
+// header returns header of given parameter name.
+func (c CSVConfig) header(name string) string {
+	for header, mapped := range c.Mapping {
+		if mapped == name {
+			return header
+		}
+	}
+	return name
+}
+
+// CSVRecord is a single row of CSV file, comment along with its label or verdict.
+type CSVRecord struct {
+	// Line is number of the line in file at which the row starts, header is line 1.
+	Line    int
+	Comment *Comment
+	// Spam is nil when spam column is absent or empty, or verdict is undecided.
+	Spam   *bool
+	GUID   string
+	ProTip string
+	Reason string
+	Error  string
+}
+
+// NewCSVRecord returns record of comment with result of its check.
+func NewCSVRecord(c *Comment, verdict Verdict, err error) CSVRecord {
+	record := CSVRecord{Comment: c}
+	if err != nil {
+		record.Error = err.Error()
+		return record
+	}
+	if !verdict.Undecided {
+		spam := verdict.Spam
+		record.Spam = &spam
+	}
+	record.GUID = verdict.GUID
+	record.ProTip = verdict.ProTip
+	record.Reason = verdict.Reason
+	return record
+}
+
+// CSVReader reads comments from CSV file with header row.
+type CSVReader struct {
+	reader  *csv.Reader
+	config  CSVConfig
+	columns []string
+}
+
+// NewCSVReader returns reader of CSV data, header row is read immediately.
+func NewCSVReader(r io.Reader, config CSVConfig) (*CSVReader, error) {
+	reader := csv.NewReader(r)
+	if config.Comma != 0 {
+		reader.Comma = config.Comma
+	}
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read CSV header")
+	}
+	columns := make([]string, len(header))
+	for i, h := range header {
+		columns[i] = config.column(strings.TrimSpace(h))
+	}
+	return &CSVReader{reader: reader, config: config, columns: columns}, nil
+}
+
+// Read returns next record, or io.EOF when there are no more records. Malformed rows are reported with *LineError, and
+// reading can continue with the next row.
+func (r *CSVReader) Read() (CSVRecord, error) {
+	row, err := r.reader.Read()
+	if err == io.EOF {
+		return CSVRecord{}, io.EOF
+	}
+	if parseErr, ok := err.(*csv.ParseError); ok {
+		return CSVRecord{}, &LineError{Line: parseErr.StartLine, Err: errors.Wrap(parseErr, "cannot read CSV row")}
+	}
+	if err != nil {
+		return CSVRecord{}, errors.Wrap(err, "cannot read CSV row")
+	}
+
+	line, _ := r.reader.FieldPos(0)
+	record := CSVRecord{Line: line}
+	values := url.Values{}
+	honeypotValue, hasHoneypotValue := "", false
+	for i, value := range row {
+		if i >= len(r.columns) {
+			break
+		}
+		value = r.config.unescape(value)
+		switch name := r.columns[i]; name {
+		case CSVSpamColumn:
+			if record.Spam, err = parseSpamLabel(value); err != nil {
+				return record, &LineError{Line: line, Err: errors.Wrapf(err, "cannot parse spam column in line %d", line)}
+			}
+		case CSVGUIDColumn:
+			record.GUID = value
+		case CSVProTipColumn:
+			record.ProTip = value
+		case CSVReasonColumn:
+			record.Reason = value
+		case CSVErrorColumn:
+			record.Error = value
+		case CSVHoneypotValueColumn:
+			honeypotValue, hasHoneypotValue = value, true
+		case "comment_context[]":
+			if value != "" {
+				values[name] = strings.Split(value, csvContextSeparator)
+			}
+		case "comment_date_gmt", "comment_post_modified_gmt":
+			if value == "" {
+				continue
+			}
+			date, err := time.Parse(r.config.dateLayout(), value)
+			if err != nil {
+				return record, &LineError{Line: line, Err: errors.Wrapf(err, "cannot parse %s in line %d", name, line)}
+			}
+			values.Set(name, date.UTC().Format(time.RFC3339))
+		default:
+			values.Set(name, value)
+		}
+	}
+	record.Comment = CommentFromValues(values)
+	if hasHoneypotValue {
+		record.Comment.HoneypotValue = honeypotValue
+	}
+	return record, nil
+}
+
+// Labelled returns LabelledReader of CSV records, so CSV dataset can be used with Evaluate. Each record must have
+// spam column set.
+func (r *CSVReader) Labelled() LabelledReader {
+	return csvLabelledReader{r}
+}
+
+type csvLabelledReader struct {
+	reader *CSVReader
+}
+
+func (r csvLabelledReader) Read() (LabelledComment, error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		return LabelledComment{}, err
+	}
+	if record.Spam == nil {
+		return LabelledComment{}, &LineError{Line: record.Line, Err: errors.Errorf("missing spam label in line %d", record.Line)}
+	}
+	return LabelledComment{Line: record.Line, Spam: *record.Spam, Comment: record.Comment}, nil
+}
+
+func parseSpamLabel(value string) (*bool, error) {
+	var spam bool
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "":
+		return nil, nil
+	case "spam", "yes", "y":
+		spam = true
+	case "ham", "no", "n":
+		spam = false
+	default:
+		var err error
+		if spam, err = strconv.ParseBool(value); err != nil {
+			return nil, err
+		}
+	}
+	return &spam, nil
+}
+
+// CSVWriter writes comments with their verdicts as CSV, with column for each Comment field and verdict columns. Cells
+// that could be evaluated as formulas are escaped, unless CSVConfig.KeepFormulas is set.
+type CSVWriter struct {
+	writer *csv.Writer
+	config CSVConfig
+}
+
+// NewCSVWriter returns CSV writer, header row is written immediately.
+func NewCSVWriter(w io.Writer, config CSVConfig) (*CSVWriter, error) {
+	writer := csv.NewWriter(w)
+	if config.Comma != 0 {
+		writer.Comma = config.Comma
+	}
+	var header []string
+	columns := append(commentColumns(), CSVHoneypotValueColumn)
+	for _, name := range append(columns, csvVerdictColumns...) {
+		header = append(header, config.header(name))
+	}
+	if err := writer.Write(header); err != nil {
+		return nil, errors.Wrap(err, "cannot write CSV header")
+	}
+	return &CSVWriter{writer: writer, config: config}, nil
+}
+
+// Write writes record as CSV row, dates are formatted with configured layout. Flush must be called after last record.
+func (w *CSVWriter) Write(record CSVRecord) error {
+	var row []string
+	v := reflect.ValueOf(Comment{})
+	if record.Comment != nil {
+		v = reflect.ValueOf(record.Comment).Elem()
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := tagName(t.Field(i).Tag.Get("form"))
+		if name == "-" {
+			continue
+		}
+		switch field := v.Field(i); field.Kind() {
+		case reflect.Slice:
+			row = append(row, strings.Join(field.Interface().([]string), csvContextSeparator))
+		default:
+			row = append(row, w.formatValue(name, field.String()))
+		}
+	}
+	row = append(row, v.FieldByName("HoneypotValue").String())
+	spam := ""
+	if record.Spam != nil {
+		spam = strconv.FormatBool(*record.Spam)
+	}
+	row = append(row, spam, record.GUID, record.ProTip, record.Reason, record.Error)
+	for i, value := range row {
+		row[i] = w.config.escape(value)
+	}
+	if err := w.writer.Write(row); err != nil {
+		return errors.Wrap(err, "cannot write CSV row")
+	}
+	return nil
+}
+
+func (w *CSVWriter) formatValue(name, value string) string {
+	if value == "" || (name != "comment_date_gmt" && name != "comment_post_modified_gmt") {
+		return value
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return value
+	}
+	return date.Format(w.config.dateLayout())
+}
+
+// Flush writes buffered rows and returns error that occurred during writing.
+func (w *CSVWriter) Flush() error {
+	w.writer.Flush()
+	return errors.Wrap(w.writer.Error(), "cannot write CSV")
+}
+
+// commentColumns returns Akismet's parameter names of Comment fields, in order of fields.
+func commentColumns() []string {
+	var columns []string
+	t := reflect.TypeOf(Comment{})
+	for i := 0; i < t.NumField(); i++ {
+		if name := tagName(t.Field(i).Tag.Get("form")); name != "-" {
+			columns = append(columns, name)
+		}
+	}
+	return columns
+}
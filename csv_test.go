@@ -0,0 +1,231 @@
+package akismet
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func boolPtr(b bool) *bool { return &b }
+
+func TestCSVReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		config   CSVConfig
+		expected []CSVRecord
+		expErr   string
+	}{{
+		name: "columns named after parameters",
+		data: "user_ip,user_agent,comment_content,comment_date_gmt,comment_context[],spam,unknown\n" +
+			"1.2.3.4,Mozilla/6.16,\"Hello, world\",2020-01-02T03:04:05Z,\"title\ntags\",true,ignored\n" +
+			"5.6.7.8,curl,,,,,\n",
+		expected: []CSVRecord{{
+			Line: 2,
+			Comment: &Comment{
+				UserIP: "1.2.3.4", UserAgent: "Mozilla/6.16", Content: "Hello, world", Created: "2020-01-02T03:04:05Z",
+				Context: []string{"title", "tags"},
+			},
+			Spam: boolPtr(true),
+		}, {
+			// multiline cell in previous row
+			Line:    4,
+			Comment: &Comment{UserIP: "5.6.7.8", UserAgent: "curl"},
+		}},
+	}, {
+		name: "escaped formulas",
+		data: "user_ip,comment_content,reason\n1.2.3.4,\"'=HYPERLINK(\"\"http://spam.com\"\")\",'+1\n1.2.3.4,'tis,''-1\n" +
+			"1.2.3.4,'\t=1+1,\"'\r=1\"\n",
+		config: CSVConfig{UnescapeFormulas: true},
+		expected: []CSVRecord{{
+			Line:    2,
+			Comment: &Comment{UserIP: "1.2.3.4", Content: `=HYPERLINK("http://spam.com")`},
+			Reason:  "+1",
+		}, {
+			Line:    3,
+			Comment: &Comment{UserIP: "1.2.3.4", Content: "'tis"},
+			Reason:  "'-1",
+		}, {
+			Line:    4,
+			Comment: &Comment{UserIP: "1.2.3.4", Content: "\t=1+1"},
+			Reason:  "\r=1",
+		}},
+	}, {
+		name: "texts from other sources are not unescaped",
+		data: "user_ip,comment_content\n1.2.3.4,'=)\n",
+		expected: []CSVRecord{{
+			Line:    2,
+			Comment: &Comment{UserIP: "1.2.3.4", Content: "'=)"},
+		}},
+	}, {
+		name:   "escaping disabled",
+		data:   "user_ip,comment_content\n1.2.3.4,'=1+1\n",
+		config: CSVConfig{KeepFormulas: true},
+		expected: []CSVRecord{{
+			Line:    2,
+			Comment: &Comment{UserIP: "1.2.3.4", Content: "'=1+1"},
+		}},
+	}, {
+		name: "honeypot value column",
+		data: "user_ip,honeypot_field_name,website_confirm,honeypot_value\n1.2.3.4,website_confirm,ignored,http://spam.com\n",
+		expected: []CSVRecord{{
+			Line:    2,
+			Comment: &Comment{UserIP: "1.2.3.4", HoneypotFieldName: "website_confirm", HoneypotValue: "http://spam.com"},
+		}},
+	}, {
+		name: "mapped columns, date layout and delimiter",
+		data: "IP;Browser;Date;Status;Akismet GUID\n" +
+			"1.2.3.4;Mozilla/6.16;02.01.2020 03:04;spam;guid-1\n" +
+			"1.2.3.4;Mozilla/6.16;;ham;\n",
+		config: CSVConfig{
+			Mapping: CSVMapping{
+				"IP": "user_ip", "Browser": "user_agent", "Date": "comment_date_gmt", "Status": "spam", "Akismet GUID": "guid",
+			},
+			DateLayout: "02.01.2006 15:04",
+			Comma:      ';',
+		},
+		expected: []CSVRecord{{
+			Line:    2,
+			Comment: &Comment{UserIP: "1.2.3.4", UserAgent: "Mozilla/6.16", Created: "2020-01-02T03:04:00Z"},
+			Spam:    boolPtr(true),
+			GUID:    "guid-1",
+		}, {
+			Line:    3,
+			Comment: &Comment{UserIP: "1.2.3.4", UserAgent: "Mozilla/6.16"},
+			Spam:    boolPtr(false),
+		}},
+	}, {
+		name:   "invalid date",
+		data:   "user_ip,comment_date_gmt\n1.2.3.4,yesterday\n",
+		expErr: `cannot parse comment_date_gmt in line 2: parsing time "yesterday" as "2006-01-02T15:04:05Z07:00": cannot parse "yesterday" as "2006"`,
+	}, {
+		name:   "invalid spam label",
+		data:   "user_ip,spam\n1.2.3.4,maybe\n",
+		expErr: `cannot parse spam column in line 2: strconv.ParseBool: parsing "maybe": invalid syntax`,
+	}, {
+		name:   "invalid spam label after multiline cell",
+		data:   "comment_content,spam\n\"multi\nline\",yes\n1.2.3.4,maybe\n",
+		expErr: `cannot parse spam column in line 4: strconv.ParseBool: parsing "maybe": invalid syntax`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := NewCSVReader(strings.NewReader(tt.data), tt.config)
+			if err != nil {
+				t.Fatalf("Expected error to be nil, but got '%v'", err)
+			}
+			var records []CSVRecord
+			for {
+				record, err := reader.Read()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					if err.Error() != tt.expErr {
+						t.Errorf("Expected error to be '%s', but got '%v'", tt.expErr, err)
+					}
+					return
+				}
+				records = append(records, record)
+			}
+			if tt.expErr != "" {
+				t.Errorf("Expected error '%s', but got nil", tt.expErr)
+			}
+			if !reflect.DeepEqual(tt.expected, records) {
+				t.Errorf("Expected records to be \n'%+v', but got \n'%+v'", tt.expected, records)
+			}
+		})
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	config := CSVConfig{
+		Mapping:    CSVMapping{"IP": "user_ip", "Status": "spam"},
+		DateLayout: "2006-01-02 15:04",
+	}
+	records := []CSVRecord{
+		NewCSVRecord(&Comment{
+			UserIP: "1.2.3.4", UserAgent: "Mozilla/6.16", Content: "Hello, world", Created: "2020-01-02T03:04:00Z",
+			Context: []string{"title", "tags"},
+		}, Verdict{Spam: true, GUID: "guid-1", ProTip: ProTipDiscard}, nil),
+		NewCSVRecord(&Comment{UserIP: "5.6.7.8", UserAgent: "curl"}, Verdict{Spam: true}, errors.New("check failed")),
+		NewCSVRecord(&Comment{
+			UserIP: "9.9.9.9", UserAgent: "curl", Content: "=1+1", HoneypotFieldName: "fax", HoneypotValue: "@spam",
+		}, Verdict{Spam: true, Undecided: true, Reason: "-"}, nil),
+	}
+
+	buffer := &bytes.Buffer{}
+	writer, err := NewCSVWriter(buffer, config)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got '%v'", err)
+	}
+	for _, record := range records {
+		if err := writer.Write(record); err != nil {
+			t.Fatalf("Expected error to be nil, but got '%v'", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("Expected error to be nil, but got '%v'", err)
+	}
+
+	header := strings.SplitN(buffer.String(), "\n", 2)[0]
+	expHeader := "IP,user_agent,referrer,permalink,comment_type,comment_author,comment_author_email,comment_author_url," +
+		"comment_content,blog_lang,blog_charset,user_role,comment_date_gmt,comment_post_modified_gmt,is_test," +
+		"recheck_reason,honeypot_field_name,comment_context[],honeypot_value,Status,guid,pro_tip,reason,error"
+	if header != expHeader {
+		t.Errorf("Expected header to be \n'%s', but got \n'%s'", expHeader, header)
+	}
+	if !strings.Contains(buffer.String(), ",2020-01-02 03:04,") {
+		t.Errorf("Expected date to be formatted with layout, but got '%s'", buffer.String())
+	}
+	if !strings.HasSuffix(buffer.String(), ",'=1+1,,,,,,,,fax,,'@spam,,,,'-,\n") {
+		t.Errorf("Expected formulas to be escaped and undecided verdict to have no spam label, but got '%s'", buffer.String())
+	}
+
+	// written file can be read back
+	config.UnescapeFormulas = true
+	reader, err := NewCSVReader(buffer, config)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got '%v'", err)
+	}
+	// first record has multiline context
+	lines := []int{2, 4, 5}
+	for i, exp := range records {
+		exp.Line = lines[i]
+		record, err := reader.Read()
+		if err != nil {
+			t.Fatalf("Expected error to be nil, but got '%v'", err)
+		}
+		if !reflect.DeepEqual(exp, record) {
+			t.Errorf("Expected record to be \n'%+v', but got \n'%+v'", exp, record)
+		}
+	}
+}
+
+func TestCSVLabelledReader(t *testing.T) {
+	data := "comment_type,comment_content,spam\n" +
+		"comment,buy spam,yes\n" +
+		"comment,hello,no\n" +
+		"trackback,missed,spam\n"
+	reader, err := NewCSVReader(strings.NewReader(data), CSVConfig{})
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got '%v'", err)
+	}
+	report, err := Evaluate(context.Background(), contentChecker(), reader.Labelled(), EvalOptions{})
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got '%v'", err)
+	}
+	exp := ConfusionMatrix{TruePositives: 1, TrueNegatives: 1, FalseNegatives: 1}
+	if report.Matrix != exp {
+		t.Errorf("Expected matrix to be '%+v', but got '%+v'", exp, report.Matrix)
+	}
+
+	reader, _ = NewCSVReader(strings.NewReader("comment_content,spam\nhello,\n"), CSVConfig{})
+	if _, err := reader.Labelled().Read(); err == nil || err.Error() != "missing spam label in line 2" {
+		t.Errorf("Expected missing label error, but got '%v'", err)
+	}
+}
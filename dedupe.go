@@ -0,0 +1,224 @@
+package akismet
+
+import (
+	"container/list"
+	"context"
+	stderr "errors"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultDeduperMaxEntries = 10000
+	defaultDeduperTTL        = 24 * time.Hour
+)
+
+var (
+	// ErrDuplicateFeedback is returned by FeedbackDeduper when feedback repeats what was already reported.
+	ErrDuplicateFeedback = stderr.New("feedback already reported")
+	// ErrFeedbackSuperseded is returned by FeedbackDeduper when feedback was dropped in favour of a later one.
+	ErrFeedbackSuperseded = stderr.New("feedback superseded by later one")
+)
+
+// FeedbackDeduperConfig configures FeedbackDeduper.
+type FeedbackDeduperConfig struct {
+	// Settle is a time each call waits for, it's dropped when there was a later call for the same comment in the
+	// meantime. Zero means feedback is sent immediately.
+	Settle time.Duration
+	// MaxEntries is a number of reported comments remembered, least recently reported ones are forgotten first.
+	// Defaults to 10000.
+	MaxEntries int
+	// TTL is a time after which reported comment is forgotten, defaults to 24 hours.
+	TTL time.Duration
+}
+
+// submitter sends feedback about comments, Akismet client implements it.
+type submitter interface {
+	SubmitSpam(ctx context.Context, c *Comment, opts ...CallOpt) error
+	SubmitHam(ctx context.Context, c *Comment, opts ...CallOpt) error
+}
+
+// FeedbackDeduper wraps client's SubmitSpam and SubmitHam, skipping feedback that repeats what was already reported
+// for given comment, and collapsing spam/ham flip-flops made within settle time into the final state. Skipped
+// feedback is reported with ErrDuplicateFeedback or ErrFeedbackSuperseded. Feedback for the same comment is sent one
+// at a time, concurrent call waits for the one in progress, so it's skipped only when that one succeeded.
+//
+// Comments are identified by idempotency key (i.e. GUID returned by check) when it's set with WithIdempotencyKey,
+// and by comment's fingerprint otherwise. Feedback with WithSkipCache is always sent.
+type FeedbackDeduper struct {
+	client submitter
+	settle time.Duration
+
+	mu       sync.Mutex
+	reported *feedbackCache
+	pending  map[string]int
+	// inFlight holds channels closed when feedback for given key is sent.
+	inFlight map[string]chan struct{}
+}
+
+// NewFeedbackDeduper returns new deduper using given client, or anything else sending feedback.
+func NewFeedbackDeduper(client submitter, config FeedbackDeduperConfig) *FeedbackDeduper {
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = defaultDeduperMaxEntries
+	}
+	if config.TTL <= 0 {
+		config.TTL = defaultDeduperTTL
+	}
+	return &FeedbackDeduper{
+		client:   client,
+		settle:   config.Settle,
+		reported: newFeedbackCache(config.MaxEntries, config.TTL),
+		pending:  map[string]int{},
+		inFlight: map[string]chan struct{}{},
+	}
+}
+
+// SubmitSpam reports comment as spam, unless it's already reported as such or superseded by a later call.
+func (d *FeedbackDeduper) SubmitSpam(ctx context.Context, c *Comment, opts ...CallOpt) error {
+	return d.submit(ctx, true, c, opts)
+}
+
+// SubmitHam reports comment as ham, unless it's already reported as such or superseded by a later call.
+func (d *FeedbackDeduper) SubmitHam(ctx context.Context, c *Comment, opts ...CallOpt) error {
+	return d.submit(ctx, false, c, opts)
+}
+
+func (d *FeedbackDeduper) submit(ctx context.Context, spam bool, c *Comment, opts []CallOpt) error {
+	co, err := newCallOpts(opts)
+	if err != nil {
+		return errors.Wrap(err, "error applying call options")
+	}
+	key := co.idempotencyKey
+	if key == "" {
+		key = c.Fingerprint()
+	}
+
+	if !co.skipCache && d.settle > 0 {
+		d.mu.Lock()
+		d.pending[key]++
+		call := d.pending[key]
+		d.mu.Unlock()
+
+		timer := time.NewTimer(d.settle)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			d.mu.Lock()
+			if d.pending[key] == call {
+				delete(d.pending, key)
+			}
+			d.mu.Unlock()
+			return ctx.Err()
+		}
+
+		d.mu.Lock()
+		superseded := d.pending[key] != call
+		if !superseded {
+			delete(d.pending, key)
+		}
+		d.mu.Unlock()
+		if superseded {
+			return ErrFeedbackSuperseded
+		}
+	}
+
+	d.mu.Lock()
+	for {
+		done, ok := d.inFlight[key]
+		if !ok {
+			break
+		}
+		d.mu.Unlock()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		d.mu.Lock()
+	}
+	if previous, ok := d.reported.get(key); ok && previous == spam && !co.skipCache {
+		d.mu.Unlock()
+		return ErrDuplicateFeedback
+	}
+	done := make(chan struct{})
+	d.inFlight[key] = done
+	d.mu.Unlock()
+
+	send := d.client.SubmitHam
+	if spam {
+		send = d.client.SubmitSpam
+	}
+	err = send(ctx, c, opts...)
+
+	d.mu.Lock()
+	if err == nil {
+		d.reported.set(key, spam)
+	}
+	delete(d.inFlight, key)
+	close(done)
+	d.mu.Unlock()
+	return err
+}
+
+// feedbackCache remembers reported state of comments, evicting least recently reported and expired ones. It's not safe
+// for concurrent use.
+type feedbackCache struct {
+	maxEntries int
+	ttl        time.Duration
+	now        func() time.Time
+
+	order *list.List
+	items map[string]*list.Element
+}
+
+type feedbackEntry struct {
+	key      string
+	spam     bool
+	reported time.Time
+}
+
+func newFeedbackCache(maxEntries int, ttl time.Duration) *feedbackCache {
+	return &feedbackCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		now:        time.Now,
+		order:      list.New(),
+		items:      map[string]*list.Element{},
+	}
+}
+
+func (c *feedbackCache) get(key string) (bool, bool) {
+	elem, ok := c.items[key]
+	if !ok {
+		return false, false
+	}
+	entry := elem.Value.(*feedbackEntry)
+	if c.now().Sub(entry.reported) > c.ttl {
+		c.remove(key)
+		return false, false
+	}
+	return entry.spam, true
+}
+
+func (c *feedbackCache) set(key string, spam bool) {
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*feedbackEntry)
+		entry.spam, entry.reported = spam, c.now()
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.order.PushFront(&feedbackEntry{key: key, spam: spam, reported: c.now()})
+	for c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back().Value.(*feedbackEntry).key)
+	}
+}
+
+func (c *feedbackCache) remove(key string) {
+	if elem, ok := c.items[key]; ok {
+		c.order.Remove(elem)
+		delete(c.items, key)
+	}
+}
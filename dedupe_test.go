@@ -0,0 +1,250 @@
+package akismet
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestFeedbackDeduper(t *testing.T) {
+	comment := &Comment{UserIP: "1.1.1.1", UserAgent: "Mozilla/6.16", Content: "lorem ipsum"}
+	otherComment := &Comment{UserIP: "2.2.2.2", UserAgent: "Mozilla/6.16", Content: "dolor sit amet"}
+
+	tests := []struct {
+		name     string
+		settle   time.Duration
+		failing  int
+		run      func(d *FeedbackDeduper) []error
+		expErrs  []error
+		expCalls []string
+	}{{
+		name: "duplicated spam is sent once",
+		run: func(d *FeedbackDeduper) []error {
+			return []error{
+				d.SubmitSpam(context.Background(), comment),
+				d.SubmitSpam(context.Background(), comment),
+				d.SubmitSpam(context.Background(), otherComment),
+			}
+		},
+		expErrs:  []error{nil, ErrDuplicateFeedback, nil},
+		expCalls: []string{"submit-spam 1.1.1.1", "submit-spam 2.2.2.2"},
+	}, {
+		name: "changed state is sent",
+		run: func(d *FeedbackDeduper) []error {
+			return []error{
+				d.SubmitSpam(context.Background(), comment),
+				d.SubmitHam(context.Background(), comment),
+				d.SubmitHam(context.Background(), comment),
+			}
+		},
+		expErrs:  []error{nil, nil, ErrDuplicateFeedback},
+		expCalls: []string{"submit-spam 1.1.1.1", "submit-ham 1.1.1.1"},
+	}, {
+		name:   "flip-flops within settle time are collapsed into final state",
+		settle: 50 * time.Millisecond,
+		run: func(d *FeedbackDeduper) []error {
+			errs := make([]error, 3)
+			wg := &sync.WaitGroup{}
+			calls := []func(context.Context, *Comment, ...CallOpt) error{d.SubmitSpam, d.SubmitHam, d.SubmitSpam}
+			for i, call := range calls {
+				wg.Add(1)
+				go func(i int, call func(context.Context, *Comment, ...CallOpt) error) {
+					defer wg.Done()
+					errs[i] = call(context.Background(), comment)
+				}(i, call)
+				time.Sleep(5 * time.Millisecond)
+			}
+			wg.Wait()
+			return errs
+		},
+		expErrs:  []error{ErrFeedbackSuperseded, ErrFeedbackSuperseded, nil},
+		expCalls: []string{"submit-spam 1.1.1.1"},
+	}, {
+		name: "comments are identified by idempotency key",
+		run: func(d *FeedbackDeduper) []error {
+			return []error{
+				d.SubmitSpam(context.Background(), comment, WithIdempotencyKey("guid-1")),
+				d.SubmitSpam(context.Background(), otherComment, WithIdempotencyKey("guid-1")),
+			}
+		},
+		expErrs:  []error{nil, ErrDuplicateFeedback},
+		expCalls: []string{"submit-spam 1.1.1.1"},
+	}, {
+		name:   "feedback with skip cache is always sent",
+		settle: time.Hour,
+		run: func(d *FeedbackDeduper) []error {
+			return []error{
+				d.SubmitSpam(context.Background(), comment, WithSkipCache()),
+				d.SubmitSpam(context.Background(), comment, WithSkipCache()),
+			}
+		},
+		expErrs:  []error{nil, nil},
+		expCalls: []string{"submit-spam 1.1.1.1", "submit-spam 1.1.1.1"},
+	}, {
+		name:    "failed feedback is not remembered",
+		failing: 1,
+		run: func(d *FeedbackDeduper) []error {
+			if err := d.SubmitSpam(context.Background(), comment); err == nil {
+				t.Errorf("Expected first call to fail, but got no error")
+			}
+			return []error{d.SubmitSpam(context.Background(), comment)}
+		},
+		expErrs:  []error{nil},
+		expCalls: []string{"submit-spam 1.1.1.1", "submit-spam 1.1.1.1"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mu := &sync.Mutex{}
+			calls := []string{}
+			failing := tt.failing
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseForm(); err != nil {
+					t.Fatalf("got error parsing request form: '%v'", err)
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				calls = append(calls, strings.TrimPrefix(r.URL.Path, "/deadbeef/")+" "+r.PostForm.Get("user_ip"))
+				if failing > 0 {
+					failing--
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				w.Write([]byte(spamHamResponse))
+			}))
+			defer ts.Close()
+
+			cli := &akismetClient{
+				key:        "deadbeef",
+				blogUrl:    "http://some-blog.com",
+				httpClient: &http.Client{},
+				akismetUrl: ts.URL + "/%s/%s",
+			}
+			errs := tt.run(NewFeedbackDeduper(cli, FeedbackDeduperConfig{Settle: tt.settle}))
+			if !reflect.DeepEqual(tt.expErrs, errs) {
+				t.Errorf("Expected errors to be '%v', but got '%v'", tt.expErrs, errs)
+			}
+			if !reflect.DeepEqual(tt.expCalls, calls) {
+				t.Errorf("Expected calls to be '%v', but got '%v'", tt.expCalls, calls)
+			}
+		})
+	}
+}
+
+type submitterMock struct {
+	started chan struct{}
+	release chan error
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (m *submitterMock) SubmitSpam(context.Context, *Comment, ...CallOpt) error {
+	m.mu.Lock()
+	m.calls++
+	m.mu.Unlock()
+	m.started <- struct{}{}
+	return <-m.release
+}
+
+func (m *submitterMock) SubmitHam(ctx context.Context, c *Comment, opts ...CallOpt) error {
+	return m.SubmitSpam(ctx, c, opts...)
+}
+
+func TestFeedbackDeduperConcurrentCalls(t *testing.T) {
+	comment := &Comment{UserIP: "1.1.1.1", UserAgent: "Mozilla/6.16"}
+	tests := []struct {
+		name     string
+		firstErr error
+		expErr   error
+		expCalls int
+	}{{
+		name:     "concurrent call is duplicate when the first one succeeds",
+		expErr:   ErrDuplicateFeedback,
+		expCalls: 1,
+	}, {
+		name:     "concurrent call is sent when the first one fails",
+		firstErr: errors.New("submit failed"),
+		expCalls: 2,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &submitterMock{started: make(chan struct{}, 2), release: make(chan error, 2)}
+			d := NewFeedbackDeduper(mock, FeedbackDeduperConfig{})
+
+			firstErr := make(chan error)
+			go func() { firstErr <- d.SubmitSpam(context.Background(), comment) }()
+			<-mock.started
+			secondErr := make(chan error)
+			go func() { secondErr <- d.SubmitSpam(context.Background(), comment) }()
+
+			select {
+			case err := <-secondErr:
+				t.Fatalf("Expected concurrent call to wait for the first one, but it returned '%v'", err)
+			case <-time.After(20 * time.Millisecond):
+			}
+			mock.release <- tt.firstErr
+			if err := <-firstErr; err != tt.firstErr {
+				t.Errorf("Expected first error to be '%v', but got '%v'", tt.firstErr, err)
+			}
+			if tt.expCalls > 1 {
+				<-mock.started
+				mock.release <- nil
+			}
+			if err := <-secondErr; err != tt.expErr {
+				t.Errorf("Expected second error to be '%v', but got '%v'", tt.expErr, err)
+			}
+			if mock.calls != tt.expCalls {
+				t.Errorf("Expected %d calls, but got %d", tt.expCalls, mock.calls)
+			}
+		})
+	}
+}
+
+func TestFeedbackCache(t *testing.T) {
+	now := time.Date(2019, 6, 30, 13, 43, 12, 0, time.UTC)
+	cache := newFeedbackCache(2, time.Hour)
+	cache.now = func() time.Time { return now }
+
+	cache.set("1", true)
+	cache.set("2", false)
+	cache.get("1")
+	cache.set("1", false)
+	cache.set("3", true)
+	if _, ok := cache.get("2"); ok {
+		t.Errorf("Expected least recently reported entry to be evicted")
+	}
+	if spam, ok := cache.get("1"); !ok || spam {
+		t.Errorf("Expected entry to be kept with updated state, but got '%v', '%v'", spam, ok)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, ok := cache.get("3"); ok {
+		t.Errorf("Expected expired entry to be forgotten")
+	}
+	if len(cache.items) != 1 || cache.order.Len() != 1 {
+		t.Errorf("Expected expired entry to be removed, but got %d entries", len(cache.items))
+	}
+}
+
+func TestCommentFingerprint(t *testing.T) {
+	comment := Comment{UserIP: "1.1.1.1", UserAgent: "Mozilla/6.16", Content: "lorem ipsum"}
+	same := comment
+	same.RecheckReason = RecheckReasonEdit
+	same.IsTest = "1"
+	other := comment
+	other.Content = "lorem ipsum!"
+
+	if comment.Fingerprint() != same.Fingerprint() {
+		t.Errorf("Expected fingerprint to ignore request related fields")
+	}
+	if comment.Fingerprint() == other.Fingerprint() {
+		t.Errorf("Expected fingerprint to differ for other content")
+	}
+}
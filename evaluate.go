@@ -0,0 +1,247 @@
+package akismet
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// LabelledComment is a comment with known classification, used to evaluate checkers.
+type LabelledComment struct {
+	// Line is position of the comment in the dataset, used in reports.
+	Line    int      `json:"-"`
+	Spam    bool     `json:"spam"`
+	Comment *Comment `json:"comment"`
+}
+
+// LabelledReader reads labelled comments one by one, returning io.EOF after the last one. Malformed entries are
+// reported with *LineError, after which reading can continue.
+type LabelledReader interface {
+	Read() (LabelledComment, error)
+}
+
+// LineError is an error of single malformed entry of dataset, which doesn't prevent reading next ones.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return e.Err.Error()
+}
+
+// Cause returns underlying error, so it's unwrapped by errors.Cause.
+func (e *LineError) Cause() error {
+	return e.Err
+}
+
+type jsonlLabelledReader struct {
+	reader *bufio.Reader
+	line   int
+}
+
+// NewJSONLLabelledReader returns reader of JSONL dataset, where each line holds a label and a comment, i.e.
+// {"spam":true,"comment":{"user_ip":"1.2.3.4",...}}.
+func NewJSONLLabelledReader(r io.Reader) LabelledReader {
+	return &jsonlLabelledReader{reader: bufio.NewReader(r)}
+}
+
+func (r *jsonlLabelledReader) Read() (LabelledComment, error) {
+	for {
+		data, err := r.reader.ReadBytes('\n')
+		if len(data) > 0 {
+			r.line++
+			if data = bytes.TrimSpace(data); len(data) > 0 {
+				labelled := LabelledComment{}
+				if err := json.Unmarshal(data, &labelled); err != nil {
+					return labelled, &LineError{Line: r.line, Err: errors.Wrapf(err, "cannot decode line %d", r.line)}
+				}
+				if labelled.Comment == nil {
+					return labelled, &LineError{Line: r.line, Err: errors.Errorf("missing comment in line %d", r.line)}
+				}
+				labelled.Line = r.line
+				return labelled, nil
+			}
+		}
+		if err == io.EOF {
+			return LabelledComment{}, io.EOF
+		}
+		if err != nil {
+			return LabelledComment{}, errors.Wrap(err, "error reading input")
+		}
+	}
+}
+
+// ConfusionMatrix counts checker's verdicts against labels, spam is the positive class.
+type ConfusionMatrix struct {
+	TruePositives  int `json:"true_positives"`
+	FalsePositives int `json:"false_positives"`
+	TrueNegatives  int `json:"true_negatives"`
+	FalseNegatives int `json:"false_negatives"`
+}
+
+func (m *ConfusionMatrix) add(expected, actual bool) {
+	switch {
+	case expected && actual:
+		m.TruePositives++
+	case expected:
+		m.FalseNegatives++
+	case actual:
+		m.FalsePositives++
+	default:
+		m.TrueNegatives++
+	}
+}
+
+// Total returns number of counted verdicts.
+func (m ConfusionMatrix) Total() int {
+	return m.TruePositives + m.FalsePositives + m.TrueNegatives + m.FalseNegatives
+}
+
+// Precision returns fraction of comments marked as spam that are spam.
+func (m ConfusionMatrix) Precision() float64 {
+	return ratio(m.TruePositives, m.TruePositives+m.FalsePositives)
+}
+
+// Recall returns fraction of spam that was marked as spam.
+func (m ConfusionMatrix) Recall() float64 {
+	return ratio(m.TruePositives, m.TruePositives+m.FalseNegatives)
+}
+
+// Accuracy returns fraction of correct verdicts.
+func (m ConfusionMatrix) Accuracy() float64 {
+	return ratio(m.TruePositives+m.TrueNegatives, m.Total())
+}
+
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
+
+// Misclassified is a comment for which checker's verdict doesn't match its label.
+type Misclassified struct {
+	Line     int      `json:"line"`
+	Comment  *Comment `json:"comment"`
+	Expected bool     `json:"expected_spam"`
+	Verdict  Verdict  `json:"verdict"`
+}
+
+// EvalError is an error of checking single comment.
+type EvalError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// EvalReport is result of evaluation. Comments that failed to be checked or couldn't be read, or got undecided verdict,
+// are not counted in confusion matrices.
+type EvalReport struct {
+	Matrix ConfusionMatrix `json:"matrix"`
+	// ByType holds confusion matrix for each comment type.
+	ByType        map[string]ConfusionMatrix `json:"by_type"`
+	Misclassified []Misclassified            `json:"misclassified,omitempty"`
+	Undecided     int                        `json:"undecided"`
+	Errors        []EvalError                `json:"errors,omitempty"`
+}
+
+// EvalOptions configures Evaluate run.
+type EvalOptions struct {
+	// Concurrency is a number of comments checked at the same time, defaults to 1.
+	Concurrency int
+	// RateLimit is a maximum number of checks made per second, zero means no limit.
+	RateLimit float64
+	// MaxMisclassified limits number of misclassified examples kept in report, zero means no limit.
+	MaxMisclassified int
+}
+
+type evalResult struct {
+	labelled LabelledComment
+	verdict  Verdict
+	err      error
+}
+
+// Evaluate checks labelled comments read from r with checker, i.e. Akismet client, and reports how its verdicts match
+// labels. Misclassified examples and errors are ordered by line. Malformed entries (see LineError) are recorded in
+// report errors and skipped. When run is interrupted, report of comments checked so far is returned along with the
+// error.
+func Evaluate(ctx context.Context, checker Checker, r LabelledReader, opts EvalOptions) (*EvalReport, error) {
+	report := &EvalReport{ByType: map[string]ConfusionMatrix{}}
+	var lineErrs []EvalError
+	readErr := runWorkers(ctx, poolOptions{concurrency: opts.Concurrency, rateLimit: opts.RateLimit},
+		func(send func(LabelledComment) error) (err error) {
+			lineErrs, err = readLabelled(r, send)
+			return err
+		},
+		func(labelled LabelledComment) evalResult {
+			verdict, err := checker.CheckVerdict(ctx, labelled.Comment)
+			return evalResult{labelled: labelled, verdict: verdict, err: err}
+		},
+		report.add,
+	)
+	report.Errors = append(report.Errors, lineErrs...)
+	sort.Slice(report.Misclassified, func(i, j int) bool {
+		return report.Misclassified[i].Line < report.Misclassified[j].Line
+	})
+	if opts.MaxMisclassified > 0 && len(report.Misclassified) > opts.MaxMisclassified {
+		report.Misclassified = report.Misclassified[:opts.MaxMisclassified]
+	}
+	sort.Slice(report.Errors, func(i, j int) bool {
+		return report.Errors[i].Line < report.Errors[j].Line
+	})
+
+	if readErr != nil {
+		return report, readErr
+	}
+	return report, ctx.Err()
+}
+
+func (r *EvalReport) add(result evalResult) {
+	if result.err != nil {
+		r.Errors = append(r.Errors, EvalError{Line: result.labelled.Line, Error: result.err.Error()})
+		return
+	}
+	if result.verdict.Undecided {
+		r.Undecided++
+		return
+	}
+	expected := result.labelled.Spam
+	r.Matrix.add(expected, result.verdict.Spam)
+	byType := r.ByType[result.labelled.Comment.Type]
+	byType.add(expected, result.verdict.Spam)
+	r.ByType[result.labelled.Comment.Type] = byType
+	if expected != result.verdict.Spam {
+		r.Misclassified = append(r.Misclassified, Misclassified{
+			Line:     result.labelled.Line,
+			Comment:  result.labelled.Comment,
+			Expected: expected,
+			Verdict:  result.verdict,
+		})
+	}
+}
+
+// readLabelled sends labelled comments read from r to jobs, errors of malformed entries are returned as eval errors.
+func readLabelled(r LabelledReader, send func(LabelledComment) error) ([]EvalError, error) {
+	var lineErrs []EvalError
+	for {
+		labelled, err := r.Read()
+		if err == io.EOF {
+			return lineErrs, nil
+		}
+		if lineErr, ok := err.(*LineError); ok {
+			lineErrs = append(lineErrs, EvalError{Line: lineErr.Line, Error: lineErr.Error()})
+			continue
+		}
+		if err != nil {
+			return lineErrs, err
+		}
+		if err := send(labelled); err != nil {
+			return lineErrs, err
+		}
+	}
+}
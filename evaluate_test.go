@@ -0,0 +1,163 @@
+package akismet
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+const evalDataset = `{"spam":true,"comment":{"comment_type":"comment","comment_content":"buy spam"}}
+{"spam":false,"comment":{"comment_type":"comment","comment_content":"nice post"}}
+
+{"spam":true,"comment":{"comment_type":"trackback","comment_content":"missed"}}
+{"spam":false,"comment":{"comment_type":"comment","comment_content":"not spam, really"}}
+{"spam":false,"comment":{"comment_type":"trackback","comment_content":"unsure"}}
+{"spam":true,"comment":{"comment_type":"comment","comment_content":"fail"}}
+{"spam":false,"comment":{"comment_type":"comment","comment_content":"hello"}}
+`
+
+func contentChecker() Checker {
+	return CheckerFunc(func(_ context.Context, c *Comment, _ ...CallOpt) (Verdict, error) {
+		switch {
+		case c.Content == "unsure":
+			return Verdict{Undecided: true}, nil
+		case c.Content == "fail":
+			return Verdict{Spam: true}, errors.New("check failed")
+		}
+		return Verdict{Spam: strings.Contains(c.Content, "spam")}, nil
+	})
+}
+
+func TestEvaluate(t *testing.T) {
+	expMisclassified := []Misclassified{{
+		Line:     4,
+		Comment:  &Comment{Type: "trackback", Content: "missed"},
+		Expected: true,
+	}, {
+		Line:     5,
+		Comment:  &Comment{Type: "comment", Content: "not spam, really"},
+		Expected: false,
+		Verdict:  Verdict{Spam: true},
+	}}
+
+	tests := []struct {
+		name     string
+		opts     EvalOptions
+		expected *EvalReport
+	}{{
+		name: "full report",
+		opts: EvalOptions{Concurrency: 3},
+		expected: &EvalReport{
+			Matrix: ConfusionMatrix{TruePositives: 1, FalsePositives: 1, TrueNegatives: 2, FalseNegatives: 1},
+			ByType: map[string]ConfusionMatrix{
+				"comment":   {TruePositives: 1, FalsePositives: 1, TrueNegatives: 2},
+				"trackback": {FalseNegatives: 1},
+			},
+			Misclassified: expMisclassified,
+			Undecided:     1,
+			Errors:        []EvalError{{Line: 7, Error: "check failed"}},
+		},
+	}, {
+		name: "limited misclassified examples",
+		opts: EvalOptions{MaxMisclassified: 1},
+		expected: &EvalReport{
+			Matrix: ConfusionMatrix{TruePositives: 1, FalsePositives: 1, TrueNegatives: 2, FalseNegatives: 1},
+			ByType: map[string]ConfusionMatrix{
+				"comment":   {TruePositives: 1, FalsePositives: 1, TrueNegatives: 2},
+				"trackback": {FalseNegatives: 1},
+			},
+			Misclassified: expMisclassified[:1],
+			Undecided:     1,
+			Errors:        []EvalError{{Line: 7, Error: "check failed"}},
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Evaluate(context.Background(), contentChecker(), NewJSONLLabelledReader(strings.NewReader(evalDataset)), tt.opts)
+			if err != nil {
+				t.Fatalf("Expected error to be nil, but got '%v'", err)
+			}
+			if !reflect.DeepEqual(tt.expected, report) {
+				t.Errorf("Expected report to be \n'%+v', but got \n'%+v'", tt.expected, report)
+			}
+		})
+	}
+}
+
+func TestEvaluateWithClient(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		form, _ := url.ParseQuery(string(body))
+		fmt.Fprint(w, strings.Contains(form.Get("comment_content"), "spam"))
+	}))
+	defer ts.Close()
+	cli := &akismetClient{
+		key:        "deadbeef",
+		blogUrl:    "http://some-blog.com",
+		httpClient: &http.Client{},
+		akismetUrl: ts.URL + "/%s/%s",
+	}
+	dataset := `{"spam":true,"comment":{"user_ip":"1.2.3.4","user_agent":"Mozilla/6.16","comment_content":"spam"}}
+{"spam":true,"comment":{"user_ip":"1.2.3.4","user_agent":"Mozilla/6.16","comment_content":"hidden"}}
+{"spam":false,"comment":{"user_ip":"1.2.3.4","user_agent":"Mozilla/6.16","comment_content":"ham"}}
+`
+
+	report, err := Evaluate(context.Background(), cli, NewJSONLLabelledReader(strings.NewReader(dataset)), EvalOptions{RateLimit: 1000})
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got '%v'", err)
+	}
+	exp := ConfusionMatrix{TruePositives: 1, TrueNegatives: 1, FalseNegatives: 1}
+	if report.Matrix != exp {
+		t.Errorf("Expected matrix to be '%+v', but got '%+v'", exp, report.Matrix)
+	}
+	if report.Matrix.Precision() != 1 || report.Matrix.Recall() != 0.5 || report.Matrix.Accuracy() != 2.0/3 {
+		t.Errorf("Expected precision 1, recall 0.5 and accuracy 0.67, but got %v, %v and %v",
+			report.Matrix.Precision(), report.Matrix.Recall(), report.Matrix.Accuracy())
+	}
+}
+
+func TestEvaluateInvalidDataset(t *testing.T) {
+	dataset := `{"spam":true,"comment":{"comment_content":"spam"}}
+{
+{"spam":true}
+{"spam":false,"comment":{"comment_content":"hello"}}
+`
+	report, err := Evaluate(context.Background(), contentChecker(), NewJSONLLabelledReader(strings.NewReader(dataset)), EvalOptions{})
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got '%v'", err)
+	}
+	expErrors := []EvalError{
+		{Line: 2, Error: "cannot decode line 2: unexpected end of JSON input"},
+		{Line: 3, Error: "missing comment in line 3"},
+	}
+	if !reflect.DeepEqual(expErrors, report.Errors) {
+		t.Errorf("Expected errors to be '%+v', but got '%+v'", expErrors, report.Errors)
+	}
+	if expMatrix := (ConfusionMatrix{TruePositives: 1, TrueNegatives: 1}); report.Matrix != expMatrix {
+		t.Errorf("Expected matrix to be '%+v', but got '%+v'", expMatrix, report.Matrix)
+	}
+}
+
+type failingLabelledReader struct{}
+
+func (failingLabelledReader) Read() (LabelledComment, error) {
+	return LabelledComment{}, errors.New("read error")
+}
+
+func TestEvaluateReadError(t *testing.T) {
+	report, err := Evaluate(context.Background(), contentChecker(), failingLabelledReader{}, EvalOptions{})
+	if err == nil || err.Error() != "read error" {
+		t.Errorf("Expected error to be 'read error', but got '%v'", err)
+	}
+	if report == nil {
+		t.Error("Expected partial report")
+	}
+}
@@ -1,3 +1,9 @@
 module github.com/Alkemic/akismet
 
-require github.com/pkg/errors v0.8.1
+go 1.27.1
+
+require (
+	github.com/pkg/errors v0.8.1
+	golang.org/x/net v0.57.0
+	golang.org/x/text v0.40.0
+)
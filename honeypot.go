@@ -0,0 +1,167 @@
+package akismet
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	stderr "errors"
+	"fmt"
+	"html/template"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultHoneypotField = "website_confirm"
+	defaultTokenField    = "akismet_form_token"
+	defaultTokenMaxAge   = 24 * time.Hour
+	tokenNonceSize       = 12
+)
+
+var (
+	// ErrFormTokenInvalid indicates that form token is missing, malformed or its signature doesn't match.
+	ErrFormTokenInvalid = stderr.New("invalid form token")
+	// ErrFormTokenExpired indicates that form was rendered too long ago.
+	ErrFormTokenExpired = stderr.New("form token expired")
+	// ErrSubmissionTooFast indicates that form was submitted faster than a human could fill it.
+	ErrSubmissionTooFast = stderr.New("form submitted too fast")
+	// ErrReservedFieldName indicates that configured field name collides with Akismet's parameter.
+	ErrReservedFieldName = stderr.New("field name is reserved")
+)
+
+// FormGuardConfig configures FormGuard.
+type FormGuardConfig struct {
+	// HoneypotField is a name of hidden field that humans leave empty, defaults to "website_confirm". It's sent to
+	// Akismet as parameter, so it cannot be one of Akismet's parameters, i.e. comment_content.
+	HoneypotField string
+	// TokenField is a name of hidden field holding signed token, defaults to "akismet_form_token".
+	TokenField string
+	// MinDelay is minimal time between rendering and submitting form, faster submissions are rejected.
+	MinDelay time.Duration
+	// MaxAge is maximal time between rendering and submitting form, defaults to 24 hours, negative value means no
+	// limit.
+	MaxAge time.Duration
+}
+
+// FormGuard generates hidden honeypot and signed timestamp fields for HTML comment forms, and verifies them on submit.
+type FormGuard struct {
+	secret []byte
+	config FormGuardConfig
+	now    func() time.Time
+}
+
+// FormTokens holds hidden fields that should be rendered in comment form.
+type FormTokens struct {
+	HoneypotField string
+	TokenField    string
+	Token         string
+}
+
+// NewFormGuard returns new form guard, secret is used to sign tokens and must be kept private. Error is returned when
+// configured field names collide with Akismet's parameters, or with each other.
+func NewFormGuard(secret []byte, config FormGuardConfig) (*FormGuard, error) {
+	if config.HoneypotField == "" {
+		config.HoneypotField = defaultHoneypotField
+	}
+	if config.TokenField == "" {
+		config.TokenField = defaultTokenField
+	}
+	if config.MaxAge == 0 {
+		config.MaxAge = defaultTokenMaxAge
+	}
+	for _, name := range []string{config.HoneypotField, config.TokenField} {
+		if reservedParams[name] {
+			return nil, errors.Wrapf(ErrReservedFieldName, "field '%s'", name)
+		}
+	}
+	if config.HoneypotField == config.TokenField {
+		return nil, errors.Wrapf(ErrReservedFieldName, "honeypot and token fields are both '%s'", config.TokenField)
+	}
+	return &FormGuard{
+		secret: secret,
+		config: config,
+		now:    time.Now,
+	}, nil
+}
+
+// Generate returns hidden fields for a newly rendered form.
+func (g *FormGuard) Generate() FormTokens {
+	return g.GenerateBound("")
+}
+
+// GenerateBound returns hidden fields for a newly rendered form, with token bound to given value, i.e. session id or
+// client's IP address. Such token is accepted only by VerifyBound with the same value.
+func (g *FormGuard) GenerateBound(binding string) FormTokens {
+	nonce := make([]byte, tokenNonceSize)
+	rand.Read(nonce)
+	payload := strconv.FormatInt(g.now().Unix(), 10) + ":" + base64.RawURLEncoding.EncodeToString(nonce) + ":" +
+		g.config.HoneypotField
+	return FormTokens{
+		HoneypotField: g.config.HoneypotField,
+		TokenField:    g.config.TokenField,
+		Token:         base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + g.sign(payload, binding),
+	}
+}
+
+// Verify checks token submitted with form, rejecting forged, expired and too fast submissions, and populates comment
+// with honeypot field name and value, so Akismet receives the honeypot signal.
+func (g *FormGuard) Verify(form url.Values, c *Comment) error {
+	return g.VerifyBound(form, "", c)
+}
+
+// VerifyBound works like Verify, but accepts only token generated by GenerateBound with the same binding.
+func (g *FormGuard) VerifyBound(form url.Values, binding string, c *Comment) error {
+	parts := strings.Split(form.Get(g.config.TokenField), ".")
+	if len(parts) != 2 {
+		return ErrFormTokenInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || !hmac.Equal([]byte(g.sign(string(payload), binding)), []byte(parts[1])) {
+		return ErrFormTokenInvalid
+	}
+	fields := strings.SplitN(string(payload), ":", 3)
+	if len(fields) != 3 {
+		return ErrFormTokenInvalid
+	}
+	unix, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return ErrFormTokenInvalid
+	}
+
+	elapsed := g.now().Sub(time.Unix(unix, 0))
+	if elapsed < g.config.MinDelay {
+		return errors.Wrapf(ErrSubmissionTooFast, "submitted after %v", elapsed)
+	}
+	if g.config.MaxAge > 0 && elapsed > g.config.MaxAge {
+		return errors.Wrapf(ErrFormTokenExpired, "submitted after %v", elapsed)
+	}
+
+	c.HoneypotFieldName = fields[2]
+	c.HoneypotValue = form.Get(fields[2])
+	return nil
+}
+
+// sign returns signature of payload and binding, binding is only signed, so it's not disclosed in the token.
+func (g *FormGuard) sign(payload, binding string) string {
+	mac := hmac.New(sha256.New, g.secret)
+	mac.Write([]byte(payload))
+	mac.Write([]byte{0})
+	mac.Write([]byte(binding))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// HTML returns hidden form fields, honeypot field is hidden from humans and skipped by keyboard navigation.
+func (t FormTokens) HTML() template.HTML {
+	return template.HTML(fmt.Sprintf(
+		`<div style="display:none" aria-hidden="true"><input type="text" name="%s" value="" tabindex="-1" autocomplete="off"></div>`+
+			`<input type="hidden" name="%s" value="%s">`,
+		template.HTMLEscapeString(t.HoneypotField),
+		template.HTMLEscapeString(t.TokenField),
+		template.HTMLEscapeString(t.Token),
+	))
+}
@@ -0,0 +1,159 @@
+package akismet
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestFormGuard(t *testing.T) {
+	rendered := time.Date(2019, 6, 30, 13, 43, 12, 0, time.UTC)
+	guard, _ := NewFormGuard([]byte("secret"), FormGuardConfig{
+		MinDelay: 3 * time.Second,
+		MaxAge:   time.Hour,
+	})
+	guard.now = func() time.Time { return rendered }
+	tokens := guard.Generate()
+	boundTokens := guard.GenerateBound("session-1")
+	otherGuard, _ := NewFormGuard([]byte("other secret"), FormGuardConfig{})
+	otherTokens := otherGuard.Generate()
+
+	tests := []struct {
+		name        string
+		form        url.Values
+		binding     string
+		submitted   time.Time
+		expCause    error
+		expHoneypot string
+	}{{
+		name:      "success with empty honeypot",
+		form:      url.Values{tokens.TokenField: {tokens.Token}, tokens.HoneypotField: {""}},
+		submitted: rendered.Add(time.Minute),
+	}, {
+		name:        "success with filled honeypot passed to comment",
+		form:        url.Values{tokens.TokenField: {tokens.Token}, tokens.HoneypotField: {"http://spam.com"}},
+		submitted:   rendered.Add(time.Minute),
+		expHoneypot: "http://spam.com",
+	}, {
+		name:      "error when submitted too fast",
+		form:      url.Values{tokens.TokenField: {tokens.Token}},
+		submitted: rendered.Add(time.Second),
+		expCause:  ErrSubmissionTooFast,
+	}, {
+		name:      "error when token expired",
+		form:      url.Values{tokens.TokenField: {tokens.Token}},
+		submitted: rendered.Add(2 * time.Hour),
+		expCause:  ErrFormTokenExpired,
+	}, {
+		name:      "error when token is missing",
+		form:      url.Values{},
+		submitted: rendered.Add(time.Minute),
+		expCause:  ErrFormTokenInvalid,
+	}, {
+		name:      "error when token is signed with other secret",
+		form:      url.Values{tokens.TokenField: {otherTokens.Token}},
+		submitted: rendered.Add(time.Minute),
+		expCause:  ErrFormTokenInvalid,
+	}, {
+		name:      "success with token bound to the same value",
+		form:      url.Values{boundTokens.TokenField: {boundTokens.Token}},
+		binding:   "session-1",
+		submitted: rendered.Add(time.Minute),
+	}, {
+		name:      "error when token is bound to other value",
+		form:      url.Values{boundTokens.TokenField: {boundTokens.Token}},
+		binding:   "session-2",
+		submitted: rendered.Add(time.Minute),
+		expCause:  ErrFormTokenInvalid,
+	}, {
+		name:      "error when bound token is verified without binding",
+		form:      url.Values{boundTokens.TokenField: {boundTokens.Token}},
+		submitted: rendered.Add(time.Minute),
+		expCause:  ErrFormTokenInvalid,
+	}, {
+		name:      "error when token is tampered",
+		form:      url.Values{tokens.TokenField: {"MTU2MTkwMjE5MjpmYXg." + tokens.Token[len(tokens.Token)-43:]}},
+		submitted: rendered.Add(time.Minute),
+		expCause:  ErrFormTokenInvalid,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard.now = func() time.Time { return tt.submitted }
+			comment := &Comment{}
+			err := guard.VerifyBound(tt.form, tt.binding, comment)
+			if errors.Cause(err) != tt.expCause {
+				t.Errorf("Expected error cause to be '%v', but got '%v'", tt.expCause, err)
+			}
+			if err != nil {
+				return
+			}
+			if comment.HoneypotFieldName != "website_confirm" || comment.HoneypotValue != tt.expHoneypot {
+				t.Errorf("Expected honeypot to be 'website_confirm'='%s', but got '%s'='%s'",
+					tt.expHoneypot, comment.HoneypotFieldName, comment.HoneypotValue)
+			}
+		})
+	}
+}
+
+func TestFormGuardDefaults(t *testing.T) {
+	rendered := time.Date(2019, 6, 30, 13, 43, 12, 0, time.UTC)
+	guard, err := NewFormGuard([]byte("secret"), FormGuardConfig{})
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got '%v'", err)
+	}
+	guard.now = func() time.Time { return rendered }
+	tokens := guard.Generate()
+	if tokens.Token == guard.Generate().Token {
+		t.Errorf("Expected tokens generated at the same time to differ, but got '%s' twice", tokens.Token)
+	}
+
+	guard.now = func() time.Time { return rendered.Add(25 * time.Hour) }
+	err = guard.Verify(url.Values{tokens.TokenField: {tokens.Token}}, &Comment{})
+	if errors.Cause(err) != ErrFormTokenExpired {
+		t.Errorf("Expected error cause to be '%v', but got '%v'", ErrFormTokenExpired, err)
+	}
+
+	unlimited, _ := NewFormGuard([]byte("secret"), FormGuardConfig{MaxAge: -1})
+	unlimited.now = guard.now
+	if err := unlimited.Verify(url.Values{tokens.TokenField: {tokens.Token}}, &Comment{}); err != nil {
+		t.Errorf("Expected error to be nil, but got '%v'", err)
+	}
+}
+
+func TestNewFormGuardReservedFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		config FormGuardConfig
+	}{{
+		name:   "honeypot field named after comment parameter",
+		config: FormGuardConfig{HoneypotField: "comment_content"},
+	}, {
+		name:   "honeypot field named after blog parameter",
+		config: FormGuardConfig{HoneypotField: "blog"},
+	}, {
+		name:   "token field named after comment parameter",
+		config: FormGuardConfig{TokenField: "user_ip"},
+	}, {
+		name:   "honeypot and token fields with the same name",
+		config: FormGuardConfig{HoneypotField: "fax", TokenField: "fax"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard, err := NewFormGuard([]byte("secret"), tt.config)
+			if errors.Cause(err) != ErrReservedFieldName || guard != nil {
+				t.Errorf("Expected error cause to be '%v', but got '%v'", ErrReservedFieldName, err)
+			}
+		})
+	}
+}
+
+func TestFormTokensHTML(t *testing.T) {
+	tokens := FormTokens{HoneypotField: "website_confirm", TokenField: "token", Token: `a"b`}
+	exp := `<div style="display:none" aria-hidden="true"><input type="text" name="website_confirm" value="" tabindex="-1" autocomplete="off"></div>` +
+		`<input type="hidden" name="token" value="a&#34;b">`
+	if html := string(tokens.HTML()); html != exp {
+		t.Errorf("Expected HTML to be \n'%s', but got \n'%s'", exp, html)
+	}
+}
@@ -0,0 +1,98 @@
+package akismet
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// Call describes single request to Akismet API. Interceptors may modify form and headers before passing the call on.
+type Call struct {
+	Endpoint string
+	Form     url.Values
+	Header   http.Header
+	// IdempotencyKey and SkipCache are set with call options, they are not sent to Akismet, but interceptors can use
+	// them, i.e. to cache responses.
+	IdempotencyKey string
+	SkipCache      bool
+}
+
+// Response is the outcome of call to Akismet API. Result holds parsed response: Verdict for comment check, bool for
+// key verification and nil for submits. Interceptors may replace the result, i.e. to override verdict.
+type Response struct {
+	Body   string
+	Header http.Header
+	Result interface{}
+}
+
+// Invoker performs the call, it's either the next interceptor in chain or actual request to Akismet API.
+type Invoker func(ctx context.Context, call *Call) (*Response, error)
+
+// Interceptor wraps every call to Akismet API. It must call next to continue the call, but it can as well return its
+// own response and error without calling Akismet. Response may be not nil along with error, i.e. for unusual responses.
+type Interceptor func(ctx context.Context, call *Call, next Invoker) (*Response, error)
+
+// WithInterceptors is client functional option to add interceptors, first one is the outermost.
+func WithInterceptors(interceptors ...Interceptor) OptFn {
+	return func(c *akismetClient) {
+		c.interceptors = append(c.interceptors, interceptors...)
+	}
+}
+
+// resultParser parses response body and headers of given endpoint.
+type resultParser func(body string, header http.Header) (interface{}, error)
+
+// call sends payload to given endpoint through configured interceptors.
+func (a *akismetClient) call(ctx context.Context, endpoint string, payload *url.Values, co callOpts, parse resultParser) (*Response, error) {
+	blogUrl := a.blogUrl
+	if co.blogUrl != "" {
+		blogUrl = co.blogUrl
+	}
+	payload.Set("blog", blogUrl)
+
+	header := http.Header{}
+	if a.userAgent != "" {
+		header.Set("User-Agent", a.userAgent)
+	}
+	for name, values := range a.headers {
+		header[name] = append([]string{}, values...)
+	}
+
+	if a.testMode != nil && a.testMode.Tag != "" {
+		ctx = withTestTag(ctx, a.testMode.Tag)
+	}
+
+	invoker := func(ctx context.Context, call *Call) (*Response, error) {
+		if co.redacted != nil && a.redactionReport != nil {
+			sent := url.Values{}
+			for name, values := range call.Form {
+				sent[name] = append([]string{}, values...)
+			}
+			a.redactionReport(RedactionReport{Endpoint: call.Endpoint, Sent: sent, Redacted: co.redacted})
+		}
+		body, respHeader, err := a.post(ctx, fmt.Sprintf(a.akismetUrl, a.key, call.Endpoint), call.Form, call.Header)
+		if err != nil {
+			return nil, errors.Wrap(err, "error during comment check request")
+		}
+		resp := &Response{Body: body, Header: respHeader}
+		resp.Result, err = parse(body, respHeader)
+		return resp, err
+	}
+	for i := len(a.interceptors) - 1; i >= 0; i-- {
+		interceptor, next := a.interceptors[i], invoker
+		invoker = func(ctx context.Context, call *Call) (*Response, error) {
+			return interceptor(ctx, call, next)
+		}
+	}
+
+	return invoker(ctx, &Call{
+		Endpoint:       endpoint,
+		Form:           *payload,
+		Header:         header,
+		IdempotencyKey: co.idempotencyKey,
+		SkipCache:      co.skipCache,
+	})
+}
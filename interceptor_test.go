@@ -0,0 +1,186 @@
+package akismet
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestInterceptors(t *testing.T) {
+	comment := &Comment{UserIP: "0.0.0.0", UserAgent: "Mozilla/6.16"}
+
+	var (
+		payload []byte
+		header  http.Header
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ = ioutil.ReadAll(r.Body)
+		header = r.Header.Clone()
+		switch r.URL.Path {
+		case "/deadbeef/comment-check":
+			w.Header().Set(guidHeader, "some-guid")
+			fmt.Fprint(w, "false")
+		case "/deadbeef/verify-key":
+			fmt.Fprint(w, "valid")
+		default:
+			fmt.Fprint(w, "unusual")
+		}
+	}))
+	defer ts.Close()
+	newClient := func(interceptors ...Interceptor) *akismetClient {
+		return &akismetClient{
+			key:          "deadbeef",
+			blogUrl:      "http://some-blog.com",
+			httpClient:   &http.Client{},
+			akismetUrl:   ts.URL + "/%s/%s",
+			interceptors: interceptors,
+		}
+	}
+
+	t.Run("interceptors are called in order", func(t *testing.T) {
+		var calls []string
+		record := func(name string) Interceptor {
+			return func(ctx context.Context, call *Call, next Invoker) (*Response, error) {
+				calls = append(calls, name+" before")
+				resp, err := next(ctx, call)
+				calls = append(calls, name+" after")
+				return resp, err
+			}
+		}
+		cli := newClient()
+		WithInterceptors(record("first"), record("second"))(cli)
+		WithInterceptors(record("third"))(cli)
+
+		if _, err := cli.Check(context.Background(), comment); err != nil {
+			t.Errorf("Expected error to be nil, but got '%v'", err)
+		}
+		exp := []string{"first before", "second before", "third before", "third after", "second after", "first after"}
+		if !reflect.DeepEqual(exp, calls) {
+			t.Errorf("Expected calls to be '%v', but got '%v'", exp, calls)
+		}
+	})
+
+	t.Run("interceptor sees call, response and parsed result", func(t *testing.T) {
+		var (
+			call *Call
+			resp *Response
+		)
+		cli := newClient(func(ctx context.Context, c *Call, next Invoker) (*Response, error) {
+			call = c
+			r, err := next(ctx, c)
+			resp = r
+			return r, err
+		})
+
+		if _, err := cli.Check(context.Background(), comment, WithIdempotencyKey("some-key")); err != nil {
+			t.Errorf("Expected error to be nil, but got '%v'", err)
+		}
+		if call.Endpoint != commentCheckEndpoint {
+			t.Errorf("Expected endpoint to be '%s', but got '%s'", commentCheckEndpoint, call.Endpoint)
+		}
+		if call.Form.Get("blog") != "http://some-blog.com" || call.Form.Get("user_ip") != "0.0.0.0" {
+			t.Errorf("Expected form to contain blog and comment, but got '%v'", call.Form)
+		}
+		if call.IdempotencyKey != "some-key" {
+			t.Errorf("Expected idempotency key to be 'some-key', but got '%s'", call.IdempotencyKey)
+		}
+		if resp.Body != "false" {
+			t.Errorf("Expected body to be 'false', but got '%s'", resp.Body)
+		}
+		exp := Verdict{GUID: "some-guid"}
+		if !reflect.DeepEqual(exp, resp.Result) {
+			t.Errorf("Expected result to be '%#v', but got '%#v'", exp, resp.Result)
+		}
+	})
+
+	t.Run("interceptor modifies form and headers", func(t *testing.T) {
+		cli := newClient(func(ctx context.Context, call *Call, next Invoker) (*Response, error) {
+			call.Form.Set("blog_tenant", "tenant-1")
+			call.Header.Set("X-Tenant", "tenant-1")
+			return next(ctx, call)
+		})
+
+		if _, err := cli.Verify(context.Background()); err != nil {
+			t.Errorf("Expected error to be nil, but got '%v'", err)
+		}
+		if exp := "blog=http%3A%2F%2Fsome-blog.com&blog_tenant=tenant-1&key=deadbeef"; string(payload) != exp {
+			t.Errorf("Expected payload to be '%s', but got '%s'", exp, payload)
+		}
+		if header.Get("X-Tenant") != "tenant-1" {
+			t.Errorf("Expected X-Tenant header to be 'tenant-1', but got '%s'", header.Get("X-Tenant"))
+		}
+	})
+
+	t.Run("interceptor overrides verdict", func(t *testing.T) {
+		cli := newClient(func(ctx context.Context, call *Call, next Invoker) (*Response, error) {
+			resp, err := next(ctx, call)
+			if err != nil {
+				return resp, err
+			}
+			verdict := resp.Result.(Verdict)
+			verdict.Spam = true
+			verdict.Reason = "overridden"
+			resp.Result = verdict
+			return resp, nil
+		})
+
+		verdict, err := cli.CheckVerdict(context.Background(), comment)
+		if err != nil {
+			t.Errorf("Expected error to be nil, but got '%v'", err)
+		}
+		exp := Verdict{Spam: true, GUID: "some-guid", Reason: "overridden"}
+		if !reflect.DeepEqual(exp, verdict) {
+			t.Errorf("Expected verdict to be '%#v', but got '%#v'", exp, verdict)
+		}
+	})
+
+	t.Run("interceptor short-circuits call", func(t *testing.T) {
+		payload = nil
+		cli := newClient(func(ctx context.Context, call *Call, next Invoker) (*Response, error) {
+			return &Response{Result: true}, nil
+		})
+
+		valid, err := cli.Verify(context.Background())
+		if err != nil || !valid {
+			t.Errorf("Expected key to be valid without error, but got '%v' and '%v'", valid, err)
+		}
+		if payload != nil {
+			t.Errorf("Expected Akismet not to be called, but got payload '%s'", payload)
+		}
+	})
+
+	t.Run("interceptor sees parse error", func(t *testing.T) {
+		var seen error
+		cli := newClient(func(ctx context.Context, call *Call, next Invoker) (*Response, error) {
+			resp, err := next(ctx, call)
+			seen = err
+			return resp, err
+		})
+
+		err := cli.SubmitSpam(context.Background(), comment)
+		if errors.Cause(err) != ErrUnusualResponse || errors.Cause(seen) != ErrUnusualResponse {
+			t.Errorf("Expected error cause to be '%v', but got '%v' and '%v'", ErrUnusualResponse, err, seen)
+		}
+	})
+
+	t.Run("error returned by interceptor fails check closed", func(t *testing.T) {
+		interceptorErr := errors.New("interceptor error")
+		cli := newClient(func(ctx context.Context, call *Call, next Invoker) (*Response, error) {
+			return nil, interceptorErr
+		})
+
+		verdict, err := cli.CheckVerdict(context.Background(), comment)
+		if err != interceptorErr {
+			t.Errorf("Expected error to be '%v', but got '%v'", interceptorErr, err)
+		}
+		if !verdict.Spam {
+			t.Error("Expected verdict to be spam")
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package akismet
+
+import (
+	stderr "errors"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+)
+
+// ErrInvalidLocale indicates that locale is not a valid BCP 47 language tag, i.e. "en" or "pt-BR".
+var ErrInvalidLocale = stderr.New("invalid locale")
+
+// FormatBlogLang validates locale and returns it in format expected by Akismet's blog_lang parameter, i.e. "pt-BR" and
+// "pt_BR" become "pt_br". Only language and region are kept.
+func FormatBlogLang(locale string) (string, error) {
+	tag, err := parseLocale(locale)
+	if err != nil {
+		return "", err
+	}
+	return blogLang(tag), nil
+}
+
+// parseLocale parses locale, accepting underscore as separator as well.
+func parseLocale(locale string) (language.Tag, error) {
+	tag, err := language.Parse(strings.Replace(strings.TrimSpace(locale), "_", "-", -1))
+	if err != nil {
+		return tag, errors.Wrapf(ErrInvalidLocale, "%q: %v", locale, err)
+	}
+	return tag, nil
+}
+
+func blogLang(tag language.Tag) string {
+	base, _, region := tag.Raw()
+	lang := base.String()
+	if region != (language.Region{}) {
+		lang += "_" + strings.ToLower(region.String())
+	}
+	return lang
+}
+
+// LanguageEnricher fills comment's language (blog_lang) from site's locales and language preferred by commenter.
+type LanguageEnricher struct {
+	locales []language.Tag
+	matcher language.Matcher
+}
+
+// NewLanguageEnricher returns enricher for site with given locales, i.e. "en", "pt-BR", which may be empty when site's
+// language isn't known. Invalid locale ends with ErrInvalidLocale.
+func NewLanguageEnricher(locales ...string) (*LanguageEnricher, error) {
+	e := &LanguageEnricher{}
+	for _, locale := range locales {
+		tag, err := parseLocale(locale)
+		if err != nil {
+			return nil, err
+		}
+		e.locales = append(e.locales, tag)
+	}
+	if len(e.locales) > 0 {
+		e.matcher = language.NewMatcher(e.locales)
+	}
+	return e, nil
+}
+
+// Enrich sets comment's language. Language that is already set is validated and formatted, see FormatBlogLang, it
+// may be comma separated list. Otherwise site's locale matching Accept-Language header value is used, all site's
+// locales are used when none of them matches, and the most preferred language from header is used when site's locales
+// are not configured. Invalid header is ignored, as well as wildcard and undetermined languages in it. Invalid
+// language already set ends with ErrInvalidLocale, and comment is left unchanged.
+func (e *LanguageEnricher) Enrich(c *Comment, acceptLanguage string) error {
+	if c.Language != "" {
+		return formatLanguages(c)
+	}
+	accepted := acceptedLanguages(acceptLanguage)
+
+	if e.matcher == nil {
+		if len(accepted) > 0 {
+			c.Language = blogLang(accepted[0])
+		}
+		return nil
+	}
+	if len(accepted) > 0 {
+		if _, index, confidence := e.matcher.Match(accepted...); confidence >= language.High {
+			c.Language = blogLang(e.locales[index])
+			return nil
+		}
+	}
+	langs := make([]string, len(e.locales))
+	for i, tag := range e.locales {
+		langs[i] = blogLang(tag)
+	}
+	c.Language = strings.Join(langs, ",")
+	return nil
+}
+
+// EnrichFromRequest sets comment's language using Accept-Language header of the request comment was sent with.
+func (e *LanguageEnricher) EnrichFromRequest(c *Comment, r *http.Request) error {
+	return e.Enrich(c, r.Header.Get("Accept-Language"))
+}
+
+// formatLanguages validates and formats comma separated languages of comment.
+func formatLanguages(c *Comment) error {
+	locales := strings.Split(c.Language, ",")
+	langs := make([]string, 0, len(locales))
+	for _, locale := range locales {
+		tag, err := parseLocale(locale)
+		if err != nil {
+			return err
+		}
+		if isUndetermined(tag) {
+			return errors.Wrapf(ErrInvalidLocale, "%q: undetermined language", locale)
+		}
+		langs = append(langs, blogLang(tag))
+	}
+	c.Language = strings.Join(langs, ",")
+	return nil
+}
+
+// acceptedLanguages returns languages of Accept-Language header value in order of preference, skipping wildcard and
+// undetermined ones. Invalid value gives no languages.
+func acceptedLanguages(acceptLanguage string) []language.Tag {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil {
+		return nil
+	}
+	var accepted []language.Tag
+	for _, tag := range tags {
+		if !isUndetermined(tag) {
+			accepted = append(accepted, tag)
+		}
+	}
+	return accepted
+}
+
+// isUndetermined returns true for undetermined language ("und") and multiple languages ("mul", used for wildcard).
+func isUndetermined(tag language.Tag) bool {
+	base, _, _ := tag.Raw()
+	return base.String() == "und" || base.String() == "mul"
+}
+
+// WithLanguageEnricher is client functional option enriching language of each checked or submitted comment, see
+// LanguageEnricher.Enrich. Accept-Language header is passed with WithAcceptLanguage call option. Comment itself is
+// left as it was, only the sent copy is enriched.
+func WithLanguageEnricher(e *LanguageEnricher) OptFn {
+	return func(c *akismetClient) {
+		c.languageEnricher = e
+	}
+}
+
+// WithAcceptLanguage is call functional option passing Accept-Language header of the request comment was sent with to
+// client's LanguageEnricher.
+func WithAcceptLanguage(acceptLanguage string) CallOpt {
+	return func(o *callOpts) {
+		o.acceptLanguage = acceptLanguage
+	}
+}
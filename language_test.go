@@ -0,0 +1,159 @@
+package akismet
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestFormatBlogLang(t *testing.T) {
+	tests := []struct {
+		locale   string
+		expected string
+		expErr   error
+	}{
+		{locale: "en", expected: "en"},
+		{locale: "pt-BR", expected: "pt_br"},
+		{locale: "fr_CA", expected: "fr_ca"},
+		{locale: "zh-Hant-TW", expected: "zh_tw"},
+		{locale: "english!", expErr: ErrInvalidLocale},
+		{locale: "", expErr: ErrInvalidLocale},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			lang, err := FormatBlogLang(tt.locale)
+			if errors.Cause(err) != tt.expErr {
+				t.Errorf("Expected error cause to be '%v', but got '%v'", tt.expErr, err)
+			}
+			if lang != tt.expected {
+				t.Errorf("Expected blog lang to be '%s', but got '%s'", tt.expected, lang)
+			}
+		})
+	}
+}
+
+func TestLanguageEnricher(t *testing.T) {
+	tests := []struct {
+		name           string
+		locales        []string
+		language       string
+		acceptLanguage string
+		expected       string
+		expCause       error
+	}{{
+		name:           "site locale matching accepted language",
+		locales:        []string{"en", "pt-BR"},
+		acceptLanguage: "pt-PT,pt;q=0.9,en;q=0.5",
+		expected:       "pt_br",
+	}, {
+		name:           "all site locales when none matches",
+		locales:        []string{"en", "pt-BR"},
+		acceptLanguage: "ja,ko;q=0.8",
+		expected:       "en,pt_br",
+	}, {
+		name:     "all site locales without header",
+		locales:  []string{"en", "fr_CA"},
+		expected: "en,fr_ca",
+	}, {
+		name:           "most preferred accepted language without site locales",
+		acceptLanguage: "de;q=0.5, fr-CH, fr;q=0.9",
+		expected:       "fr_ch",
+	}, {
+		name:           "invalid header is ignored",
+		acceptLanguage: "!!!,;;",
+	}, {
+		name:           "wildcard is ignored",
+		acceptLanguage: "*",
+	}, {
+		name:           "wildcard and undetermined language are ignored",
+		acceptLanguage: "*, und;q=0.9, de;q=0.5",
+		expected:       "de",
+	}, {
+		name:           "all site locales when only wildcard is accepted",
+		locales:        []string{"en", "pt-BR"},
+		acceptLanguage: "*",
+		expected:       "en,pt_br",
+	}, {
+		name:           "language already set",
+		locales:        []string{"en"},
+		language:       "pl",
+		acceptLanguage: "de",
+		expected:       "pl",
+	}, {
+		name:     "language already set is formatted",
+		language: "pt-BR, en_US",
+		expected: "pt_br,en_us",
+	}, {
+		name:     "error when language already set is invalid",
+		language: "en,not a locale",
+		expected: "en,not a locale",
+		expCause: ErrInvalidLocale,
+	}, {
+		name:     "error when language already set is undetermined",
+		language: "und",
+		expected: "und",
+		expCause: ErrInvalidLocale,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enricher, err := NewLanguageEnricher(tt.locales...)
+			if err != nil {
+				t.Fatalf("Expected error to be nil, but got '%v'", err)
+			}
+			comment := &Comment{Language: tt.language}
+			r := httptest.NewRequest("POST", "/comments", nil)
+			if tt.acceptLanguage != "" {
+				r.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			err = enricher.EnrichFromRequest(comment, r)
+			if errors.Cause(err) != tt.expCause {
+				t.Errorf("Expected error cause to be '%v', but got '%v'", tt.expCause, err)
+			}
+			if comment.Language != tt.expected {
+				t.Errorf("Expected language to be '%s', but got '%s'", tt.expected, comment.Language)
+			}
+		})
+	}
+}
+
+func TestNewLanguageEnricherInvalidLocale(t *testing.T) {
+	_, err := NewLanguageEnricher("en", "not a locale")
+	if errors.Cause(err) != ErrInvalidLocale {
+		t.Errorf("Expected error cause to be '%v', but got '%v'", ErrInvalidLocale, err)
+	}
+}
+
+func TestAkismetWithLanguageEnricher(t *testing.T) {
+	var payload []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ = ioutil.ReadAll(r.Body)
+		fmt.Fprint(w, "false")
+	}))
+	defer ts.Close()
+
+	enricher, _ := NewLanguageEnricher("en", "pt-BR")
+	cli, _ := NewAkismet("deadbeef", "http://some-blog.com", WithLanguageEnricher(enricher))
+	cli.akismetUrl = ts.URL + "/%s/%s"
+	comment := &Comment{UserIP: "0.0.0.0", UserAgent: "Mozilla/6.16"}
+
+	if _, err := cli.Check(context.Background(), comment, WithAcceptLanguage("pt-PT,pt;q=0.9")); err != nil {
+		t.Fatalf("Expected error to be nil, but got '%v'", err)
+	}
+	exp := "blog=http%3A%2F%2Fsome-blog.com&blog_lang=pt_br&user_agent=Mozilla%2F6.16&user_ip=0.0.0.0"
+	if string(payload) != exp {
+		t.Errorf("Expected payload to be \n'%s', but got \n'%s'", exp, payload)
+	}
+	if comment.Language != "" {
+		t.Errorf("Expected passed comment not to be modified, but got '%s'", comment.Language)
+	}
+
+	comment.Language = "not a locale"
+	if err := cli.SubmitHam(context.Background(), comment); errors.Cause(err) != ErrInvalidLocale {
+		t.Errorf("Expected error cause to be '%v', but got '%v'", ErrInvalidLocale, err)
+	}
+}
@@ -0,0 +1,38 @@
+package akismet
+
+import (
+	"context"
+	"time"
+)
+
+// limiter spaces out calls, so that no more than given number of them per second is made.
+type limiter struct {
+	ticker *time.Ticker
+}
+
+// newLimiter returns limiter allowing perSecond calls per second, nil limiter (which never blocks) is returned for
+// non-positive values.
+func newLimiter(perSecond float64) *limiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &limiter{ticker: time.NewTicker(time.Duration(float64(time.Second) / perSecond))}
+}
+
+func (l *limiter) wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+	select {
+	case <-l.ticker.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *limiter) stop() {
+	if l != nil {
+		l.ticker.Stop()
+	}
+}
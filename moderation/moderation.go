@@ -0,0 +1,180 @@
+// Package moderation implements comment moderation workflow on top of Akismet's check and feedback calls.
+package moderation
+
+import (
+	"context"
+	stderr "errors"
+	"sync"
+	"time"
+
+	"github.com/Alkemic/akismet"
+	"github.com/pkg/errors"
+)
+
+// State is a moderation state of comment.
+type State string
+
+const (
+	// StatePending is a state of comment waiting for moderator's decision, i.e. when Akismet check failed.
+	StatePending State = "pending"
+	// StateApproved is a state of comment visible to everyone.
+	StateApproved State = "approved"
+	// StateSpam is a state of comment hidden as spam, it can still be approved by moderator.
+	StateSpam State = "spam"
+	// StateDiscarded is a final state of comment that was removed.
+	StateDiscarded State = "discarded"
+)
+
+// ErrInvalidTransition indicates that comment cannot be moved from its current state into requested one.
+var ErrInvalidTransition = stderr.New("invalid state transition")
+
+// Akismet is a part of Akismet client used by Machine.
+type Akismet interface {
+	CheckVerdict(ctx context.Context, c *akismet.Comment, opts ...akismet.CallOpt) (akismet.Verdict, error)
+	SubmitSpam(ctx context.Context, c *akismet.Comment, opts ...akismet.CallOpt) error
+	SubmitHam(ctx context.Context, c *akismet.Comment, opts ...akismet.CallOpt) error
+}
+
+// Record is a comment along with its moderation state.
+type Record struct {
+	ID        string
+	Comment   akismet.Comment
+	State     State
+	Verdict   akismet.Verdict
+	UpdatedAt time.Time
+}
+
+// feedback is Akismet call made on transition, nil means that no call is needed.
+type feedback func(a Akismet, ctx context.Context, c *akismet.Comment, opts ...akismet.CallOpt) error
+
+// transitions lists allowed transitions. Feedback is sent when moderator overrides Akismet's verdict, or decides about
+// pending comment, which Akismet didn't judge. Discarding doesn't say whether comment was spam, so nothing is sent.
+var transitions = map[State]map[State]feedback{
+	StatePending: {
+		StateApproved:  Akismet.SubmitHam,
+		StateSpam:      Akismet.SubmitSpam,
+		StateDiscarded: nil,
+	},
+	StateApproved: {
+		StateSpam:      Akismet.SubmitSpam,
+		StateDiscarded: nil,
+	},
+	StateSpam: {
+		StateApproved:  Akismet.SubmitHam,
+		StateDiscarded: nil,
+	},
+}
+
+// Machine drives comments through moderation states, calling Akismet on transitions, and persisting them in Store.
+type Machine struct {
+	akismet Akismet
+	store   Store
+	now     func() time.Time
+
+	mu    sync.Mutex
+	locks map[string]*recordLock
+}
+
+// recordLock serializes transitions of single record, refs counts its holders and waiters.
+type recordLock struct {
+	sync.Mutex
+	refs int
+}
+
+// NewMachine returns new moderation state machine.
+func NewMachine(client Akismet, store Store) *Machine {
+	return &Machine{
+		akismet: client,
+		store:   store,
+		now:     time.Now,
+		locks:   map[string]*recordLock{},
+	}
+}
+
+// Submit checks new comment and stores it as approved, spam or discarded (when Akismet advises to discard it). When
+// check fails comment is stored as pending, and the error is returned along with the record. Call options are passed
+// to Akismet client.
+func (m *Machine) Submit(ctx context.Context, id string, c *akismet.Comment, opts ...akismet.CallOpt) (*Record, error) {
+	record := &Record{
+		ID:      id,
+		Comment: *c,
+		State:   StatePending,
+	}
+	verdict, checkErr := m.akismet.CheckVerdict(ctx, c, opts...)
+	if checkErr == nil {
+		record.Verdict = verdict
+		record.State = StateApproved
+		if verdict.Discard() {
+			record.State = StateDiscarded
+		} else if verdict.Spam {
+			record.State = StateSpam
+		}
+	}
+
+	record.UpdatedAt = m.now()
+	if err := m.store.Save(ctx, record); err != nil {
+		return nil, errors.Wrap(err, "error saving record")
+	}
+	return record, errors.Wrap(checkErr, "error checking comment")
+}
+
+// Transition moves comment into given state. When moderator overrides Akismet's verdict, it's reported as spam or ham.
+// Transitions of the same comment are serialized, and the new state is saved first, only if record wasn't changed
+// meanwhile by other process, otherwise ErrConflict is returned and no feedback is sent. When sending feedback fails,
+// record is moved back to its previous state. Call options are passed to Akismet client.
+func (m *Machine) Transition(ctx context.Context, id string, to State, opts ...akismet.CallOpt) (*Record, error) {
+	unlock := m.lock(id)
+	defer unlock()
+
+	record, err := m.store.Get(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting record")
+	}
+	if record.State == to {
+		return record, nil
+	}
+	fn, ok := transitions[record.State][to]
+	if !ok {
+		return nil, errors.Wrapf(ErrInvalidTransition, "from %s to %s", record.State, to)
+	}
+
+	previous := *record
+	record.State = to
+	record.UpdatedAt = m.now()
+	if err := m.store.CompareAndSwap(ctx, record, previous.State); err != nil {
+		return nil, errors.Wrap(err, "error saving record")
+	}
+	if fn == nil {
+		return record, nil
+	}
+	if err := fn(m.akismet, ctx, &record.Comment, opts...); err != nil {
+		if rollbackErr := m.store.CompareAndSwap(ctx, &previous, to); rollbackErr != nil {
+			return nil, errors.Wrapf(err, "error sending feedback, and restoring %s state failed: %v", previous.State, rollbackErr)
+		}
+		return nil, errors.Wrap(err, "error sending feedback")
+	}
+	return record, nil
+}
+
+// lock locks record with given id, and returns function unlocking it.
+func (m *Machine) lock(id string) func() {
+	m.mu.Lock()
+	l, ok := m.locks[id]
+	if !ok {
+		l = &recordLock{}
+		m.locks[id] = l
+	}
+	l.refs++
+	m.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		m.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(m.locks, id)
+		}
+		m.mu.Unlock()
+	}
+}
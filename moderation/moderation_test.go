@@ -0,0 +1,247 @@
+package moderation
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Alkemic/akismet"
+	"github.com/pkg/errors"
+)
+
+type akismetMock struct {
+	verdict   akismet.Verdict
+	checkErr  error
+	submitErr error
+
+	mu    sync.Mutex
+	calls []string
+}
+
+func (m *akismetMock) call(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, name)
+}
+
+func (m *akismetMock) CheckVerdict(context.Context, *akismet.Comment, ...akismet.CallOpt) (akismet.Verdict, error) {
+	m.call("check")
+	return m.verdict, m.checkErr
+}
+
+func (m *akismetMock) SubmitSpam(context.Context, *akismet.Comment, ...akismet.CallOpt) error {
+	m.call("spam")
+	return m.submitErr
+}
+
+func (m *akismetMock) SubmitHam(context.Context, *akismet.Comment, ...akismet.CallOpt) error {
+	m.call("ham")
+	return m.submitErr
+}
+
+func TestAkismetClientImplementsAkismet(t *testing.T) {
+	client, err := akismet.NewAkismet("deadbeef", "http://some-blog.com")
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got '%v'", err)
+	}
+	var _ Akismet = client
+}
+
+func TestMachineSubmit(t *testing.T) {
+	tests := []struct {
+		name     string
+		akismet  *akismetMock
+		expState State
+		expErr   string
+	}{{
+		name:     "ham is approved",
+		akismet:  &akismetMock{},
+		expState: StateApproved,
+	}, {
+		name:     "spam is held as spam",
+		akismet:  &akismetMock{verdict: akismet.Verdict{Spam: true}},
+		expState: StateSpam,
+	}, {
+		name:     "blatant spam is discarded",
+		akismet:  &akismetMock{verdict: akismet.Verdict{Spam: true, ProTip: akismet.ProTipDiscard}},
+		expState: StateDiscarded,
+	}, {
+		name:     "comment is pending when check fails",
+		akismet:  &akismetMock{verdict: akismet.Verdict{Spam: true}, checkErr: errors.New("mocked check error")},
+		expState: StatePending,
+		expErr:   "error checking comment: mocked check error",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			machine := NewMachine(tt.akismet, store)
+			machine.now = func() time.Time { return time.Date(2019, 6, 30, 13, 43, 12, 0, time.UTC) }
+			record, err := machine.Submit(context.Background(), "1", &akismet.Comment{UserIP: "1.2.3.4"})
+			if tt.expErr == "" && err != nil || tt.expErr != "" && (err == nil || err.Error() != tt.expErr) {
+				t.Errorf("Expected error to be '%s', but got '%v'", tt.expErr, err)
+			}
+			if record.State != tt.expState {
+				t.Errorf("Expected state to be '%s', but got '%s'", tt.expState, record.State)
+			}
+			stored, _ := store.Get(context.Background(), "1")
+			if stored.State != tt.expState || stored.Comment.UserIP != "1.2.3.4" || !stored.UpdatedAt.Equal(machine.now()) {
+				t.Errorf("Expected stored record to match '%+v', but got '%+v'", record, stored)
+			}
+		})
+	}
+}
+
+func TestMachineTransition(t *testing.T) {
+	tests := []struct {
+		name      string
+		from      State
+		to        State
+		submitErr error
+		expState  State
+		expCalls  []string
+		expCause  error
+		expErr    string
+	}{{
+		name:     "approving spam submits ham",
+		from:     StateSpam,
+		to:       StateApproved,
+		expState: StateApproved,
+		expCalls: []string{"ham"},
+	}, {
+		name:     "marking approved as spam submits spam",
+		from:     StateApproved,
+		to:       StateSpam,
+		expState: StateSpam,
+		expCalls: []string{"spam"},
+	}, {
+		name:     "approving pending submits ham",
+		from:     StatePending,
+		to:       StateApproved,
+		expState: StateApproved,
+		expCalls: []string{"ham"},
+	}, {
+		name:     "marking pending as spam submits spam",
+		from:     StatePending,
+		to:       StateSpam,
+		expState: StateSpam,
+		expCalls: []string{"spam"},
+	}, {
+		name:     "discarding approved doesn't call Akismet",
+		from:     StateApproved,
+		to:       StateDiscarded,
+		expState: StateDiscarded,
+	}, {
+		name:     "discarding spam doesn't call Akismet",
+		from:     StateSpam,
+		to:       StateDiscarded,
+		expState: StateDiscarded,
+	}, {
+		name:     "transition to the same state is no-op",
+		from:     StateSpam,
+		to:       StateSpam,
+		expState: StateSpam,
+	}, {
+		name:     "discarded comment cannot be approved",
+		from:     StateDiscarded,
+		to:       StateApproved,
+		expState: StateDiscarded,
+		expCause: ErrInvalidTransition,
+		expErr:   "from discarded to approved: invalid state transition",
+	}, {
+		name:      "state is restored when feedback fails",
+		from:      StateSpam,
+		to:        StateApproved,
+		submitErr: errors.New("mocked submit error"),
+		expState:  StateSpam,
+		expCalls:  []string{"ham"},
+		expErr:    "error sending feedback: mocked submit error",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			mock := &akismetMock{submitErr: tt.submitErr}
+			store := NewMemoryStore()
+			store.Save(ctx, &Record{ID: "1", State: tt.from})
+			machine := NewMachine(mock, store)
+			_, err := machine.Transition(ctx, "1", tt.to)
+			if tt.expErr == "" && err != nil || tt.expErr != "" && (err == nil || err.Error() != tt.expErr) {
+				t.Errorf("Expected error to be '%s', but got '%v'", tt.expErr, err)
+			}
+			if tt.expCause != nil && errors.Cause(err) != tt.expCause {
+				t.Errorf("Expected error cause to be '%v', but got '%v'", tt.expCause, err)
+			}
+			if !reflect.DeepEqual(tt.expCalls, mock.calls) {
+				t.Errorf("Expected Akismet calls to be '%v', but got '%v'", tt.expCalls, mock.calls)
+			}
+			stored, _ := store.Get(ctx, "1")
+			if stored.State != tt.expState {
+				t.Errorf("Expected stored state to be '%s', but got '%s'", tt.expState, stored.State)
+			}
+		})
+	}
+}
+
+func TestMachineConcurrentTransitions(t *testing.T) {
+	ctx := context.Background()
+	mock := &akismetMock{}
+	store := NewMemoryStore()
+	store.Save(ctx, &Record{ID: "1", State: StateSpam})
+	machine := NewMachine(mock, store)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := machine.Transition(ctx, "1", StateApproved); err != nil {
+				t.Errorf("Expected error to be nil, but got '%v'", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !reflect.DeepEqual([]string{"ham"}, mock.calls) {
+		t.Errorf("Expected ham to be submitted once, but got calls '%v'", mock.calls)
+	}
+	if len(machine.locks) != 0 {
+		t.Errorf("Expected record locks to be released, but got %d", len(machine.locks))
+	}
+}
+
+type conflictingStore struct {
+	*MemoryStore
+}
+
+func (s conflictingStore) CompareAndSwap(ctx context.Context, record *Record, from State) error {
+	s.MemoryStore.Save(ctx, &Record{ID: record.ID, State: StateDiscarded})
+	return s.MemoryStore.CompareAndSwap(ctx, record, from)
+}
+
+func TestMachineTransitionConflict(t *testing.T) {
+	ctx := context.Background()
+	store := conflictingStore{NewMemoryStore()}
+	store.Save(ctx, &Record{ID: "1", State: StateSpam})
+	mock := &akismetMock{}
+	machine := NewMachine(mock, store)
+
+	_, err := machine.Transition(ctx, "1", StateApproved)
+	if errors.Cause(err) != ErrConflict {
+		t.Errorf("Expected error cause to be '%v', but got '%v'", ErrConflict, err)
+	}
+	if len(mock.calls) != 0 {
+		t.Errorf("Expected no feedback to be sent on conflict, but got calls '%v'", mock.calls)
+	}
+	if stored, _ := store.Get(ctx, "1"); stored.State != StateDiscarded {
+		t.Errorf("Expected concurrent change to be kept, but got state '%s'", stored.State)
+	}
+}
+
+func TestMachineTransitionNotFound(t *testing.T) {
+	machine := NewMachine(&akismetMock{}, NewMemoryStore())
+	_, err := machine.Transition(context.Background(), "1", StateApproved)
+	if errors.Cause(err) != ErrNotFound {
+		t.Errorf("Expected error cause to be '%v', but got '%v'", ErrNotFound, err)
+	}
+}
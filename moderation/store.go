@@ -0,0 +1,86 @@
+package moderation
+
+import (
+	"context"
+	stderr "errors"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrNotFound is returned by Store when there is no record with given id.
+	ErrNotFound = stderr.New("record not found")
+	// ErrConflict is returned by Store when record was changed since it was read.
+	ErrConflict = stderr.New("record was changed concurrently")
+)
+
+// Store persists moderation records.
+type Store interface {
+	// Get returns record with given id, or ErrNotFound.
+	Get(ctx context.Context, id string) (*Record, error)
+	// Save creates or replaces record.
+	Save(ctx context.Context, record *Record) error
+	// CompareAndSwap replaces record only when stored one is still in given state, otherwise it returns ErrConflict,
+	// or ErrNotFound when there is no record with the same id.
+	CompareAndSwap(ctx context.Context, record *Record, from State) error
+}
+
+// MemoryStore is an in-memory Store, it's safe for concurrent use.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records map[string]Record
+}
+
+// NewMemoryStore returns new, empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: map[string]Record{}}
+}
+
+// Get returns copy of the stored record.
+func (s *MemoryStore) Get(_ context.Context, id string) (*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, ok := s.records[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &record, nil
+}
+
+// Save stores copy of the record.
+func (s *MemoryStore) Save(_ context.Context, record *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[record.ID] = *record
+	return nil
+}
+
+// CompareAndSwap stores copy of the record, if stored one is in given state.
+func (s *MemoryStore) CompareAndSwap(_ context.Context, record *Record, from State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.records[record.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if stored.State != from {
+		return ErrConflict
+	}
+	s.records[record.ID] = *record
+	return nil
+}
+
+// List returns copies of records in given state, ordered by id.
+func (s *MemoryStore) List(_ context.Context, state State) ([]*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	records := []*Record{}
+	for _, record := range s.records {
+		if record.State == state {
+			record := record
+			records = append(records, &record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+	return records, nil
+}
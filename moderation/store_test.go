@@ -0,0 +1,54 @@
+package moderation
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	if _, err := store.Get(ctx, "1"); err != ErrNotFound {
+		t.Errorf("Expected error to be '%v', but got '%v'", ErrNotFound, err)
+	}
+
+	record := &Record{ID: "1", State: StateSpam}
+	store.Save(ctx, record)
+	store.Save(ctx, &Record{ID: "3", State: StateApproved})
+	store.Save(ctx, &Record{ID: "2", State: StateSpam})
+	record.State = StateApproved
+
+	stored, err := store.Get(ctx, "1")
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got '%v'", err)
+	}
+	if stored.State != StateSpam {
+		t.Errorf("Expected store to keep copy of record, but got state '%s'", stored.State)
+	}
+
+	spam, _ := store.List(ctx, StateSpam)
+	exp := []*Record{{ID: "1", State: StateSpam}, {ID: "2", State: StateSpam}}
+	if !reflect.DeepEqual(exp, spam) {
+		t.Errorf("Expected records to be '%+v', but got '%+v'", exp, spam)
+	}
+}
+
+func TestMemoryStoreCompareAndSwap(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	if err := store.CompareAndSwap(ctx, &Record{ID: "1", State: StateApproved}, StateSpam); err != ErrNotFound {
+		t.Errorf("Expected error to be '%v', but got '%v'", ErrNotFound, err)
+	}
+
+	store.Save(ctx, &Record{ID: "1", State: StateSpam})
+	if err := store.CompareAndSwap(ctx, &Record{ID: "1", State: StateDiscarded}, StateApproved); err != ErrConflict {
+		t.Errorf("Expected error to be '%v', but got '%v'", ErrConflict, err)
+	}
+	if err := store.CompareAndSwap(ctx, &Record{ID: "1", State: StateApproved}, StateSpam); err != nil {
+		t.Errorf("Expected error to be nil, but got '%v'", err)
+	}
+	if stored, _ := store.Get(ctx, "1"); stored.State != StateApproved {
+		t.Errorf("Expected stored state to be '%s', but got '%s'", StateApproved, stored.State)
+	}
+}
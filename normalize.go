@@ -0,0 +1,169 @@
+package akismet
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/unicode/norm"
+)
+
+const utf8Charset = "UTF-8"
+
+// zeroWidth removes invisible characters used to split words, i.e. to evade word filters. Zero-width joiner and
+// non-joiner are kept, as they are meaningful in some scripts and emoji.
+var zeroWidth = strings.NewReplacer("\u200b", "", "\u2060", "", "\ufeff", "", "\u00ad", "")
+
+var (
+	horizontalSpace = regexp.MustCompile(`[ \t\r\f\v\x{00a0}]+`)
+	blankLines      = regexp.MustCompile(`\n{3,}`)
+)
+
+// NormalizeConfig configures normalization of comments.
+type NormalizeConfig struct {
+	// StripHTML converts HTML content to plain text, links are kept as "text (url)".
+	StripHTML bool
+	// MaxContentLength truncates content to given number of characters, zero means no limit.
+	MaxContentLength int
+}
+
+// WithNormalization is client functional option normalizing comments before they are checked or submitted. Comments
+// passed to the client are not modified.
+func WithNormalization(config NormalizeConfig) OptFn {
+	return func(c *akismetClient) {
+		c.normalize = &config
+	}
+}
+
+// Normalize cleans up comment's texts, so Akismet sees clean text: all fields are transcoded from Charset to UTF-8
+// (and Charset is set accordingly), in author, content and context zero-width characters are removed and Unicode is
+// normalized to NFC. Depending on config, HTML content is converted to text and overlong content is truncated.
+func (c *Comment) Normalize(config NormalizeConfig) error {
+	if c.Charset != "" && !strings.EqualFold(c.Charset, utf8Charset) {
+		encoding, err := htmlindex.Get(c.Charset)
+		if err != nil {
+			return errors.Wrapf(err, "unknown charset %s", c.Charset)
+		}
+		decoder := encoding.NewDecoder()
+		for _, field := range c.encodedFields() {
+			if *field, err = decoder.String(*field); err != nil {
+				return errors.Wrapf(err, "cannot transcode from %s", c.Charset)
+			}
+		}
+	}
+	c.Charset = utf8Charset
+
+	if config.StripHTML {
+		c.Content = htmlToText(c.Content)
+	}
+	fields := []*string{&c.Author, &c.Content}
+	for i := range c.Context {
+		fields = append(fields, &c.Context[i])
+	}
+	for _, field := range fields {
+		*field = norm.NFC.String(zeroWidth.Replace(strings.ToValidUTF8(*field, "\ufffd")))
+	}
+	if config.MaxContentLength > 0 && utf8.RuneCountInString(c.Content) > config.MaxContentLength {
+		c.Content = strings.TrimSpace(string([]rune(c.Content)[:config.MaxContentLength]))
+	}
+	return nil
+}
+
+// encodedFields returns all texts sent to Akismet, which are encoded with Charset.
+func (c *Comment) encodedFields() []*string {
+	fields := []*string{}
+	v := reflect.ValueOf(c).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		switch field := v.Field(i); field.Kind() {
+		case reflect.String:
+			if ptr := field.Addr().Interface().(*string); ptr != &c.Charset {
+				fields = append(fields, ptr)
+			}
+		case reflect.Slice:
+			for j := 0; j < field.Len(); j++ {
+				fields = append(fields, field.Index(j).Addr().Interface().(*string))
+			}
+		}
+	}
+	return fields
+}
+
+// htmlToText returns text of HTML document, with block elements separated by new lines.
+func htmlToText(s string) string {
+	b := &strings.Builder{}
+	tokenizer := html.NewTokenizer(strings.NewReader(s))
+	skip := 0
+	var hrefs []string
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return cleanText(b.String())
+		case html.TextToken:
+			if skip == 0 {
+				b.Write(tokenizer.Text())
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.DataAtom {
+			case atom.Script, atom.Style:
+				if token.Type == html.StartTagToken {
+					skip++
+				}
+			case atom.A:
+				href := ""
+				for _, attr := range token.Attr {
+					if attr.Key == "href" {
+						href = attr.Val
+					}
+				}
+				hrefs = append(hrefs, href)
+			case atom.Br, atom.Hr:
+				b.WriteString("\n")
+			}
+			if isBlock(token.DataAtom) {
+				b.WriteString("\n")
+			}
+		case html.EndTagToken:
+			token := tokenizer.Token()
+			switch token.DataAtom {
+			case atom.Script, atom.Style:
+				if skip > 0 {
+					skip--
+				}
+			case atom.A:
+				if len(hrefs) > 0 {
+					if href := hrefs[len(hrefs)-1]; href != "" && !strings.HasSuffix(b.String(), href) {
+						b.WriteString(" (" + href + ")")
+					}
+					hrefs = hrefs[:len(hrefs)-1]
+				}
+			}
+			if isBlock(token.DataAtom) {
+				b.WriteString("\n")
+			}
+		}
+	}
+}
+
+func isBlock(a atom.Atom) bool {
+	switch a {
+	case atom.P, atom.Div, atom.Li, atom.Ul, atom.Ol, atom.Blockquote, atom.Pre, atom.Tr, atom.Table,
+		atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		return true
+	}
+	return false
+}
+
+// cleanText collapses spaces, trims lines and removes excessive blank lines.
+func cleanText(s string) string {
+	lines := strings.Split(horizontalSpace.ReplaceAllString(s, " "), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
@@ -0,0 +1,135 @@
+package akismet
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCommentNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		comment  Comment
+		config   NormalizeConfig
+		expected Comment
+		expErr   string
+	}{{
+		name:     "plain UTF-8 text sets charset",
+		comment:  Comment{Content: "Hello"},
+		expected: Comment{Content: "Hello", Charset: "UTF-8"},
+	}, {
+		name:     "zero-width characters and NFC",
+		comment:  Comment{Author: "Jose\u0301", Content: "vi\u200bag\ufeffra", Context: []string{"cafe\u0301"}},
+		expected: Comment{Author: "José", Content: "viagra", Context: []string{"café"}, Charset: "UTF-8"},
+	}, {
+		name:     "transcoding from declared charset",
+		comment:  Comment{Author: "Zo\xeb", Content: "Za\xbf\xf3\xb3\xe6", Charset: "iso-8859-2"},
+		expected: Comment{Author: "Zoë", Content: "Zażółć", Charset: "UTF-8"},
+	}, {
+		name: "all fields are transcoded",
+		comment: Comment{
+			UserIP: "1.2.3.4", UserAgent: "Mozilla/6.16 (\xa3\xf3d\xbc)", Referrer: "http://blog.pl/?q=\xbf",
+			Permalink: "http://blog.pl/\xb6", AuthorEmail: "zo\xeb@blog.pl", AuthorURL: "http://\xb3\xf3d\xbc.pl",
+			HoneypotFieldName: "website", HoneypotValue: "\xe6ma", Context: []string{"Za\xbf\xf3\xb3\xe6"},
+			Charset: "iso-8859-2",
+		},
+		expected: Comment{
+			UserIP: "1.2.3.4", UserAgent: "Mozilla/6.16 (Łódź)", Referrer: "http://blog.pl/?q=ż",
+			Permalink: "http://blog.pl/ś", AuthorEmail: "zoë@blog.pl", AuthorURL: "http://łódź.pl",
+			HoneypotFieldName: "website", HoneypotValue: "ćma", Context: []string{"Zażółć"}, Charset: "UTF-8",
+		},
+	}, {
+		name:     "invalid UTF-8 is replaced",
+		comment:  Comment{Content: "bad \xff byte", Charset: "utf-8"},
+		expected: Comment{Content: "bad \ufffd byte", Charset: "UTF-8"},
+	}, {
+		name: "HTML converted to text",
+		comment: Comment{Content: `<p>Great&nbsp;post!</p><script>alert(1)</script>` +
+			`<p>Visit <a href="http://spam.example">my   site</a><br>and <a href="http://x.example">http://x.example</a></p>`},
+		config:   NormalizeConfig{StripHTML: true},
+		expected: Comment{Content: "Great post!\n\nVisit my site (http://spam.example)\nand http://x.example", Charset: "UTF-8"},
+	}, {
+		name:     "content truncated",
+		comment:  Comment{Content: "żółw idzie powoli"},
+		config:   NormalizeConfig{MaxContentLength: 5},
+		expected: Comment{Content: "żółw", Charset: "UTF-8"},
+	}, {
+		name:    "unknown charset",
+		comment: Comment{Content: "Hello", Charset: "klingon"},
+		expErr:  "unknown charset klingon: htmlindex: invalid encoding name",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.comment.Normalize(tt.config)
+			if tt.expErr != "" {
+				if err == nil || err.Error() != tt.expErr {
+					t.Errorf("Expected error to be '%s', but got '%v'", tt.expErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected error to be nil, but got '%v'", err)
+			}
+			if !reflect.DeepEqual(tt.expected, tt.comment) {
+				t.Errorf("Expected comment to be \n'%#v', but got \n'%#v'", tt.expected, tt.comment)
+			}
+		})
+	}
+}
+
+func TestAkismetWithNormalization(t *testing.T) {
+	var payload []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ = ioutil.ReadAll(r.Body)
+		fmt.Fprint(w, "false")
+	}))
+	defer ts.Close()
+	cli := &akismetClient{
+		key:        "deadbeef",
+		blogUrl:    "http://some-blog.com",
+		httpClient: &http.Client{},
+		akismetUrl: ts.URL + "/%s/%s",
+	}
+	WithNormalization(NormalizeConfig{StripHTML: true})(cli)
+	comment := &Comment{UserIP: "0.0.0.0", UserAgent: "Mozilla/6.16", Content: "<b>Hi</b>\u200b", Context: []string{"Title\u200b"}}
+
+	if _, err := cli.Check(context.Background(), comment); err != nil {
+		t.Fatalf("Expected error to be nil, but got '%v'", err)
+	}
+	exp := "blog=http%3A%2F%2Fsome-blog.com&blog_charset=UTF-8&comment_content=Hi&comment_context%5B%5D=Title&user_agent=Mozilla%2F6.16&user_ip=0.0.0.0"
+	if string(payload) != exp {
+		t.Errorf("Expected payload to be \n'%s', but got \n'%s'", exp, payload)
+	}
+	if comment.Content != "<b>Hi</b>\u200b" || comment.Context[0] != "Title\u200b" || comment.Charset != "" {
+		t.Errorf("Expected passed comment not to be modified, but got '%#v'", comment)
+	}
+
+	// rules and audit see the original comment
+	sink := &auditSinkMock{}
+	WithAudit(sink, nil)(cli)
+	WithPreFilter(func(c *Comment) RuleResult {
+		if strings.Contains(c.Content, "<b>") {
+			return RuleResult{Decision: RuleSpam, Reason: "bold"}
+		}
+		return RuleResult{}
+	})(cli)
+	verdict, err := cli.CheckVerdict(context.Background(), comment)
+	if err != nil || !verdict.Spam || verdict.Reason != "bold" {
+		t.Errorf("Expected rule to match original content, but got '%+v', '%v'", verdict, err)
+	}
+	if len(sink.entries) != 1 || sink.entries[0].Fingerprint != comment.Fingerprint() {
+		t.Errorf("Expected audit entry with fingerprint of original comment, but got '%+v'", sink.entries)
+	}
+	cli.preFilter = nil
+
+	comment.Charset = "klingon"
+	err = cli.SubmitSpam(context.Background(), comment)
+	if err == nil || !strings.HasPrefix(err.Error(), "error normalizing comment: unknown charset klingon") {
+		t.Errorf("Expected normalization error, but got '%v'", err)
+	}
+}
@@ -1,15 +1,55 @@
 package akismet
 
-import "net/http"
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
 
-const akismetUrl = "https://%s.rest.akismet.com/1.1/%s"
+const (
+	akismetUrl       = "https://%s.rest.akismet.com/1.1/%s"
+	libraryUserAgent = "AkismetGoClient/" + Version
+)
+
+const (
+	defaultTimeout             = 10 * time.Second
+	defaultDialTimeout         = 5 * time.Second
+	defaultKeepAlive           = 30 * time.Second
+	defaultTLSHandshakeTimeout = 5 * time.Second
+	defaultIdleConnTimeout     = 90 * time.Second
+	// all requests go to the single Akismet host, so the whole idle pool is dedicated to it
+	defaultMaxIdleConns = 16
+)
 
 // OptFn is a type for optional functional parameters to Akismet's client ctor.
 type OptFn func(c *akismetClient)
 
 func defaultOpts(c *akismetClient) {
-	c.httpClient = &http.Client{}
+	c.httpClient = newDefaultHttpClient()
 	c.akismetUrl = akismetUrl
+	c.userAgent = libraryUserAgent
+}
+
+// newDefaultHttpClient returns http client with timeouts, so hung connection doesn't block comment check forever.
+func newDefaultHttpClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   defaultDialTimeout,
+		KeepAlive: defaultKeepAlive,
+	}
+	return &http.Client{
+		Timeout: defaultTimeout,
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			TLSHandshakeTimeout:   defaultTLSHandshakeTimeout,
+			MaxIdleConns:          defaultMaxIdleConns,
+			MaxIdleConnsPerHost:   defaultMaxIdleConns,
+			IdleConnTimeout:       defaultIdleConnTimeout,
+			ExpectContinueTimeout: time.Second,
+		},
+	}
 }
 
 // WithHttpClient is client functional option to set custom httpClient.
@@ -18,3 +58,58 @@ func WithHttpClient(httpClient *http.Client) OptFn {
 		c.httpClient = httpClient
 	}
 }
+
+// WithTimeout is client functional option to set overall timeout of requests, zero means no timeout. Http client set
+// with WithHttpClient is copied, not modified.
+func WithTimeout(timeout time.Duration) OptFn {
+	return func(c *akismetClient) {
+		httpClient := c.copyHttpClient()
+		httpClient.Timeout = timeout
+		c.httpClient = httpClient
+	}
+}
+
+// WithTransport is client functional option to set transport used by http client. Http client set with
+// WithHttpClient is copied, not modified.
+func WithTransport(transport http.RoundTripper) OptFn {
+	return func(c *akismetClient) {
+		httpClient := c.copyHttpClient()
+		httpClient.Transport = transport
+		c.httpClient = httpClient
+	}
+}
+
+// WithMaxResponseSize is client functional option to set maximum size of Akismet response in bytes, larger responses
+// end with ErrResponseTooLarge.
+func WithMaxResponseSize(size int64) OptFn {
+	return func(c *akismetClient) {
+		c.maxResponseSize = size
+	}
+}
+
+// WithUserAgent is client functional option to set application name and version sent in User-Agent header, as
+// recommended by Akismet, i.e. "MyBlog/2.1 | AkismetGoClient/1.0.0".
+func WithUserAgent(app, version string) OptFn {
+	return func(c *akismetClient) {
+		c.userAgent = fmt.Sprintf("%s/%s | %s", app, version, libraryUserAgent)
+	}
+}
+
+// WithHeader is client functional option to add header sent with every request.
+func WithHeader(name, value string) OptFn {
+	return func(c *akismetClient) {
+		if c.headers == nil {
+			c.headers = http.Header{}
+		}
+		c.headers.Add(name, value)
+	}
+}
+
+// copyHttpClient returns copy of configured http client, or a new one when it's not set.
+func (c *akismetClient) copyHttpClient() *http.Client {
+	httpClient := &http.Client{}
+	if c.httpClient != nil {
+		*httpClient = *c.httpClient
+	}
+	return httpClient
+}
@@ -1,10 +1,14 @@
 package akismet
 
 import (
+	"context"
 	"crypto/tls"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestWithHttpClient(t *testing.T) {
@@ -22,9 +26,6 @@ func TestWithHttpClient(t *testing.T) {
 		name:             "nil http client",
 		withHttpClientFn: []OptFn{WithHttpClient(nil)},
 		expected:         nil,
-	}, {
-		name:     "use default http client",
-		expected: &http.Client{},
 	}, {
 		name:             "basic working example",
 		withHttpClientFn: []OptFn{WithHttpClient(httpClient)},
@@ -39,3 +40,130 @@ func TestWithHttpClient(t *testing.T) {
 		})
 	}
 }
+
+func TestDefaultHttpClient(t *testing.T) {
+	client, _ := NewAkismet("asd", "http://some-blog.com")
+	if client.httpClient.Timeout != defaultTimeout {
+		t.Errorf("expected timeout to be '%v', but got '%v'", defaultTimeout, client.httpClient.Timeout)
+	}
+	transport, ok := client.httpClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected transport to be *http.Transport, but got '%T'", client.httpClient.Transport)
+	}
+	if transport.DialContext == nil {
+		t.Error("expected dial func to be set")
+	}
+	if !transport.ForceAttemptHTTP2 {
+		t.Error("expected HTTP/2 to be attempted")
+	}
+	if transport.TLSHandshakeTimeout != defaultTLSHandshakeTimeout {
+		t.Errorf("expected TLS handshake timeout to be '%v', but got '%v'", defaultTLSHandshakeTimeout, transport.TLSHandshakeTimeout)
+	}
+	if transport.MaxIdleConnsPerHost != defaultMaxIdleConns {
+		t.Errorf("expected max idle conns per host to be '%d', but got '%d'", defaultMaxIdleConns, transport.MaxIdleConnsPerHost)
+	}
+	if transport.IdleConnTimeout != defaultIdleConnTimeout {
+		t.Errorf("expected idle conn timeout to be '%v', but got '%v'", defaultIdleConnTimeout, transport.IdleConnTimeout)
+	}
+	other, _ := NewAkismet("asd", "http://some-blog.com")
+	if other.httpClient == client.httpClient {
+		t.Error("expected each client to get its own http client")
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	httpClient := &http.Client{Timeout: time.Minute}
+
+	client, _ := NewAkismet("asd", "http://some-blog.com", WithHttpClient(httpClient), WithTimeout(time.Second))
+	if client.httpClient.Timeout != time.Second {
+		t.Errorf("expected timeout to be '%v', but got '%v'", time.Second, client.httpClient.Timeout)
+	}
+	if httpClient.Timeout != time.Minute {
+		t.Errorf("expected passed http client not to be modified, but got timeout '%v'", httpClient.Timeout)
+	}
+
+	client, _ = NewAkismet("asd", "http://some-blog.com", WithHttpClient(nil), WithTimeout(time.Second))
+	if client.httpClient == nil || client.httpClient.Timeout != time.Second {
+		t.Errorf("expected new http client with '%v' timeout, but got '%v'", time.Second, client.httpClient)
+	}
+}
+
+func TestWithTransport(t *testing.T) {
+	transport := &transportMock{}
+	httpClient := &http.Client{Timeout: time.Minute}
+
+	client, _ := NewAkismet("asd", "http://some-blog.com", WithHttpClient(httpClient), WithTransport(transport))
+	if client.httpClient.Transport != transport {
+		t.Errorf("expected transport to be '%v', but got '%v'", transport, client.httpClient.Transport)
+	}
+	if client.httpClient.Timeout != time.Minute {
+		t.Errorf("expected timeout to be kept, but got '%v'", client.httpClient.Timeout)
+	}
+	if httpClient.Transport != nil {
+		t.Errorf("expected passed http client not to be modified, but got transport '%v'", httpClient.Transport)
+	}
+
+	client, _ = NewAkismet("asd", "http://some-blog.com", WithTransport(transport))
+	if client.httpClient.Timeout != defaultTimeout {
+		t.Errorf("expected default timeout to be kept, but got '%v'", client.httpClient.Timeout)
+	}
+}
+
+func TestRequestHeaders(t *testing.T) {
+	tests := []struct {
+		name     string
+		optFns   []OptFn
+		expected http.Header
+	}{{
+		name: "default user agent",
+		expected: http.Header{
+			"User-Agent":   []string{"AkismetGoClient/" + Version},
+			"Content-Type": []string{"application/x-www-form-urlencoded"},
+		},
+	}, {
+		name:   "application user agent",
+		optFns: []OptFn{WithUserAgent("MyBlog", "2.1")},
+		expected: http.Header{
+			"User-Agent":   []string{"MyBlog/2.1 | AkismetGoClient/" + Version},
+			"Content-Type": []string{"application/x-www-form-urlencoded"},
+		},
+	}, {
+		name:   "custom headers",
+		optFns: []OptFn{WithHeader("X-Tenant", "first"), WithHeader("X-Tenant", "second"), WithHeader("X-Request-Source", "web")},
+		expected: http.Header{
+			"User-Agent":       []string{"AkismetGoClient/" + Version},
+			"Content-Type":     []string{"application/x-www-form-urlencoded"},
+			"X-Tenant":         []string{"first", "second"},
+			"X-Request-Source": []string{"web"},
+		},
+	}, {
+		name:   "content type can't be overridden",
+		optFns: []OptFn{WithHeader("Content-Type", "application/json")},
+		expected: http.Header{
+			"User-Agent":   []string{"AkismetGoClient/" + Version},
+			"Content-Type": []string{"application/x-www-form-urlencoded"},
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var header http.Header
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				header = r.Header.Clone()
+				fmt.Fprint(w, "valid")
+			}))
+			client, _ := NewAkismet("deadbeef", "http://some-blog.com", tt.optFns...)
+			client.akismetUrl = ts.URL + "/%s/%s"
+			_, err := client.Verify(context.Background())
+			ts.Close()
+			if err != nil {
+				t.Fatalf("Expected error to be nil, but got '%v'", err)
+			}
+			for _, name := range []string{"Accept-Encoding", "Content-Length"} {
+				header.Del(name)
+			}
+			if !reflect.DeepEqual(tt.expected, header) {
+				t.Errorf("Expected headers to be '%v', but got '%v'", tt.expected, header)
+			}
+		})
+	}
+}
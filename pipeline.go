@@ -0,0 +1,175 @@
+package akismet
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// PipelineConfig configures Pipeline.
+type PipelineConfig struct {
+	// Workers is a number of comments checked at the same time, defaults to 1.
+	Workers int
+	// Ordered makes results to be sent in the same order as items were received.
+	Ordered bool
+	// Buffer is a number of results that can wait for being received, before pipeline stops taking new items.
+	Buffer int
+	// ItemTimeout limits time of checking single comment, zero means no limit.
+	ItemTimeout time.Duration
+	// DrainTimeout limits time of finishing in-flight items after pipeline was stopped, zero means no limit.
+	DrainTimeout time.Duration
+}
+
+// PipelineItem is a comment pushed into pipeline, ID identifies it in results.
+type PipelineItem struct {
+	ID      string
+	Comment *Comment
+}
+
+// PipelineResult is a result of checking PipelineItem.
+type PipelineResult struct {
+	Item    PipelineItem
+	Verdict Verdict
+	Err     error
+}
+
+// Pipeline checks comments received from channel using configured number of workers, and sends results to another
+// channel.
+type Pipeline struct {
+	client Checker
+	config PipelineConfig
+}
+
+type pipelineJob struct {
+	seq  int
+	item PipelineItem
+}
+
+type pipelineResult struct {
+	seq    int
+	result PipelineResult
+}
+
+// NewPipeline returns new pipeline using given client, or any other Checker.
+func NewPipeline(client Checker, config PipelineConfig) *Pipeline {
+	if config.Workers < 1 {
+		config.Workers = 1
+	}
+	if config.Buffer < 0 {
+		config.Buffer = 0
+	}
+	return &Pipeline{
+		client: client,
+		config: config,
+	}
+}
+
+// Run checks items received from in and sends results to returned channel. Pipeline doesn't take new items when
+// results are not received, so the returned channel must be drained until it's closed. It's closed after in is closed
+// and all in-flight items are flushed. When context is done pipeline stops taking new items, and in-flight ones are
+// finished with context detached from its cancellation, limited by DrainTimeout. Items not taken are left in in.
+func (p *Pipeline) Run(ctx context.Context, in <-chan PipelineItem) <-chan PipelineResult {
+	out := make(chan PipelineResult, p.config.Buffer)
+	jobs := make(chan pipelineJob)
+	results := make(chan pipelineResult)
+	// slots limits number of items taken, but not yet sent out, which gives backpressure also in ordered mode
+	slots := make(chan struct{}, p.config.Workers+p.config.Buffer)
+	workCtx, cancelWork := context.WithCancel(context.WithoutCancel(ctx))
+	done := make(chan struct{})
+
+	go p.dispatch(ctx, in, slots, jobs)
+	go p.drain(ctx, done, cancelWork)
+
+	wg := &sync.WaitGroup{}
+	wg.Add(p.config.Workers)
+	for i := 0; i < p.config.Workers; i++ {
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				results <- pipelineResult{seq: job.seq, result: p.check(workCtx, job.item)}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+		close(done)
+	}()
+
+	go p.emit(results, slots, out)
+	return out
+}
+
+// drain cancels context of in-flight items when DrainTimeout passes after ctx is done, or when all items are done.
+func (p *Pipeline) drain(ctx context.Context, done <-chan struct{}, cancel context.CancelFunc) {
+	defer cancel()
+	select {
+	case <-done:
+		return
+	case <-ctx.Done():
+	}
+	if p.config.DrainTimeout <= 0 {
+		<-done
+		return
+	}
+	timer := time.NewTimer(p.config.DrainTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+	}
+}
+
+func (p *Pipeline) dispatch(ctx context.Context, in <-chan PipelineItem, slots chan<- struct{}, jobs chan<- pipelineJob) {
+	defer close(jobs)
+	for seq := 0; ; seq++ {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		select {
+		case item, ok := <-in:
+			if !ok {
+				return
+			}
+			jobs <- pipelineJob{seq: seq, item: item}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *Pipeline) check(ctx context.Context, item PipelineItem) PipelineResult {
+	if p.config.ItemTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.config.ItemTimeout)
+		defer cancel()
+	}
+	verdict, err := p.client.CheckVerdict(ctx, item.Comment)
+	return PipelineResult{Item: item, Verdict: verdict, Err: err}
+}
+
+func (p *Pipeline) emit(results <-chan pipelineResult, slots <-chan struct{}, out chan<- PipelineResult) {
+	defer close(out)
+	pending := map[int]PipelineResult{}
+	next := 0
+	for result := range results {
+		if !p.config.Ordered {
+			out <- result.result
+			<-slots
+			continue
+		}
+		pending[result.seq] = result.result
+		for {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			out <- r
+			<-slots
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package akismet
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func pipelineTestServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("got error parsing request form: '%v'", err)
+		}
+		content := r.PostForm.Get("comment_content")
+		if strings.HasPrefix(content, "slow") {
+			time.Sleep(50 * time.Millisecond)
+		}
+		fmt.Fprint(w, strings.HasSuffix(content, "spam"))
+	}))
+}
+
+func TestPipeline(t *testing.T) {
+	type check func(results []PipelineResult, t *testing.T)
+	checks := func(cs ...check) []check { return cs }
+
+	hasIDs := func(exp ...string) check {
+		return func(results []PipelineResult, t *testing.T) {
+			t.Helper()
+			ids := []string{}
+			for _, result := range results {
+				ids = append(ids, result.Item.ID)
+			}
+			if !reflect.DeepEqual(exp, ids) {
+				t.Errorf("Expected result ids to be '%v', but got '%v'", exp, ids)
+			}
+		}
+	}
+	hasSortedIDs := func(exp ...string) check {
+		return func(results []PipelineResult, t *testing.T) {
+			t.Helper()
+			ids := []string{}
+			for _, result := range results {
+				ids = append(ids, result.Item.ID)
+			}
+			sort.Strings(ids)
+			if !reflect.DeepEqual(exp, ids) {
+				t.Errorf("Expected result ids to be '%v', but got '%v'", exp, ids)
+			}
+		}
+	}
+	hasVerdicts := func(exp map[string]bool) check {
+		return func(results []PipelineResult, t *testing.T) {
+			t.Helper()
+			for _, result := range results {
+				if result.Err != nil || result.Verdict.Spam != exp[result.Item.ID] {
+					t.Errorf("Expected item %s to have spam verdict '%t', but got '%+v' with error '%v'",
+						result.Item.ID, exp[result.Item.ID], result.Verdict, result.Err)
+				}
+			}
+		}
+	}
+	hasErrors := func(exp ...string) check {
+		return func(results []PipelineResult, t *testing.T) {
+			t.Helper()
+			for _, result := range results {
+				for _, id := range exp {
+					if result.Item.ID == id && (result.Err == nil || !strings.Contains(result.Err.Error(), "deadline exceeded")) {
+						t.Errorf("Expected item %s to have timeout error, but got '%v'", id, result.Err)
+					}
+				}
+			}
+		}
+	}
+
+	items := []PipelineItem{
+		{ID: "1", Comment: &Comment{UserIP: "1.1.1.1", UserAgent: "Mozilla/6.16", Content: "slow spam"}},
+		{ID: "2", Comment: &Comment{UserIP: "2.2.2.2", UserAgent: "Mozilla/6.16", Content: "ham"}},
+		{ID: "3", Comment: &Comment{UserIP: "3.3.3.3", UserAgent: "Mozilla/6.16", Content: "spam"}},
+	}
+
+	tests := []struct {
+		name   string
+		config PipelineConfig
+		checks []check
+	}{{
+		name:   "ordered results",
+		config: PipelineConfig{Workers: 3, Ordered: true},
+		checks: checks(
+			hasIDs("1", "2", "3"),
+			hasVerdicts(map[string]bool{"1": true, "2": false, "3": true}),
+		),
+	}, {
+		name:   "unordered results don't wait for slow item",
+		config: PipelineConfig{Workers: 3, Buffer: 1},
+		checks: checks(
+			hasSortedIDs("1", "2", "3"),
+			func(results []PipelineResult, t *testing.T) {
+				if results[2].Item.ID != "1" {
+					t.Errorf("Expected slow item to be the last one, but got '%s'", results[2].Item.ID)
+				}
+			},
+		),
+	}, {
+		name:   "item timeout",
+		config: PipelineConfig{Workers: 2, Ordered: true, ItemTimeout: 10 * time.Millisecond},
+		checks: checks(
+			hasIDs("1", "2", "3"),
+			hasErrors("1"),
+		),
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := pipelineTestServer(t)
+			defer ts.Close()
+			cli := &akismetClient{
+				key:        "deadbeef",
+				blogUrl:    "http://some-blog.com",
+				httpClient: &http.Client{},
+				akismetUrl: ts.URL + "/%s/%s",
+			}
+
+			in := make(chan PipelineItem)
+			out := NewPipeline(cli, tt.config).Run(context.Background(), in)
+			go func() {
+				defer close(in)
+				for _, item := range items {
+					in <- item
+				}
+			}()
+			results := []PipelineResult{}
+			for result := range out {
+				results = append(results, result)
+			}
+			for _, ch := range tt.checks {
+				ch(results, t)
+			}
+		})
+	}
+}
+
+func TestPipelineCancel(t *testing.T) {
+	ts := pipelineTestServer(t)
+	defer ts.Close()
+	cli := &akismetClient{
+		key:        "deadbeef",
+		blogUrl:    "http://some-blog.com",
+		httpClient: &http.Client{},
+		akismetUrl: ts.URL + "/%s/%s",
+	}
+
+	tests := []struct {
+		name         string
+		drainTimeout time.Duration
+		expErr       string
+	}{{
+		name: "in-flight item is finished after stop",
+	}, {
+		name:         "in-flight item fails after drain timeout",
+		drainTimeout: 10 * time.Millisecond,
+		expErr:       context.Canceled.Error(),
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			in := make(chan PipelineItem, 1)
+			out := NewPipeline(cli, PipelineConfig{DrainTimeout: tt.drainTimeout}).Run(ctx, in)
+			in <- PipelineItem{ID: "1", Comment: &Comment{UserIP: "1.1.1.1", UserAgent: "Mozilla/6.16", Content: "slow spam"}}
+			time.Sleep(10 * time.Millisecond)
+			in <- PipelineItem{ID: "2", Comment: &Comment{UserIP: "1.1.1.1", UserAgent: "Mozilla/6.16"}}
+			cancel()
+
+			results := []PipelineResult{}
+			for result := range out {
+				results = append(results, result)
+			}
+			if len(results) != 1 || results[0].Item.ID != "1" {
+				t.Fatalf("Expected only in-flight item to be flushed, but got '%+v'", results)
+			}
+			if tt.expErr == "" && results[0].Err != nil || tt.expErr != "" && (results[0].Err == nil || !strings.Contains(results[0].Err.Error(), tt.expErr)) {
+				t.Errorf("Expected error to contain '%s', but got '%v'", tt.expErr, results[0].Err)
+			}
+			if tt.expErr == "" && !results[0].Verdict.Spam {
+				t.Errorf("Expected in-flight item to be checked, but got '%+v'", results[0])
+			}
+			if len(in) != 1 {
+				t.Errorf("Expected item not taken to be left in input, but got %d items", len(in))
+			}
+		})
+	}
+}
+
+func TestPipelineItemTimeoutOfAnyChecker(t *testing.T) {
+	checker := CheckerFunc(func(ctx context.Context, _ *Comment, _ ...CallOpt) (Verdict, error) {
+		select {
+		case <-time.After(300 * time.Millisecond):
+			return Verdict{}, nil
+		case <-ctx.Done():
+			return Verdict{}, ctx.Err()
+		}
+	})
+	in := make(chan PipelineItem, 1)
+	in <- PipelineItem{ID: "1", Comment: &Comment{}}
+	close(in)
+
+	start := time.Now()
+	results := []PipelineResult{}
+	for result := range NewPipeline(checker, PipelineConfig{ItemTimeout: 10 * time.Millisecond}).Run(context.Background(), in) {
+		results = append(results, result)
+	}
+	if len(results) != 1 || results[0].Err != context.DeadlineExceeded {
+		t.Errorf("Expected item to time out, but got '%+v'", results)
+	}
+	if took := time.Since(start); took >= 300*time.Millisecond {
+		t.Errorf("Expected check to be interrupted, but it took %v", took)
+	}
+}
@@ -0,0 +1,234 @@
+package akismet
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+const defaultRecheckRetryDelay = time.Minute
+
+// RecheckChange describes comment which spam verdict changed after recheck.
+type RecheckChange struct {
+	ID       string
+	Comment  *Comment
+	Previous Verdict
+	Current  Verdict
+}
+
+// RecheckConfig configures RecheckScheduler.
+type RecheckConfig struct {
+	// OnChange is called when recheck changes spam verdict of comment.
+	OnChange func(RecheckChange)
+	// OnError is called when recheck fails, failed recheck is retried after RetryDelay.
+	OnError func(id string, err error)
+	// RetryDelay is a delay after which failed recheck is retried, defaults to one minute.
+	RetryDelay time.Duration
+}
+
+// Rechecker checks comments and rechecks them with given reason, Akismet client implements it.
+type Rechecker interface {
+	Checker
+	Recheck(ctx context.Context, c *Comment, reason string, opts ...CallOpt) (Verdict, error)
+}
+
+// RecheckScheduler holds comments that Akismet advised to recheck later, re-submits them when they are due with
+// recheck_queue reason, and rechecks edited comments with edit reason, reporting verdict changes.
+type RecheckScheduler struct {
+	client Rechecker
+	config RecheckConfig
+	now    func() time.Time
+
+	mu      sync.Mutex
+	items   map[string]*recheckItem
+	queue   recheckQueue
+	updated chan struct{}
+}
+
+type recheckItem struct {
+	id      string
+	comment *Comment
+	verdict Verdict
+	due     time.Time
+	index   int
+}
+
+// NewRecheckScheduler returns new scheduler using given client, or any other Rechecker, Run must be called to process
+// scheduled rechecks.
+func NewRecheckScheduler(client Rechecker, config RecheckConfig) *RecheckScheduler {
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = defaultRecheckRetryDelay
+	}
+	return &RecheckScheduler{
+		client:  client,
+		config:  config,
+		now:     time.Now,
+		items:   map[string]*recheckItem{},
+		updated: make(chan struct{}, 1),
+	}
+}
+
+// Check checks comment identified by id, and when Akismet advises to recheck it later, schedules the recheck.
+func (s *RecheckScheduler) Check(ctx context.Context, id string, c *Comment) (Verdict, error) {
+	verdict, err := s.client.CheckVerdict(ctx, c)
+	if err != nil {
+		return verdict, err
+	}
+	s.Schedule(id, c, verdict)
+	return verdict, nil
+}
+
+// Schedule holds comment with its current verdict for recheck, when verdict advises it. Comment already held under
+// the same id is replaced.
+func (s *RecheckScheduler) Schedule(id string, c *Comment, verdict Verdict) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(id)
+	if verdict.RecheckAfter > 0 {
+		s.add(&recheckItem{id: id, comment: c, verdict: verdict, due: s.now().Add(verdict.RecheckAfter)})
+	}
+}
+
+// Edited rechecks edited comment immediately with edit reason. If comment was known to the scheduler, change of its
+// verdict is reported. When recheck fails, known comment stays scheduled and is retried after RetryDelay.
+func (s *RecheckScheduler) Edited(ctx context.Context, id string, c *Comment) (Verdict, error) {
+	s.mu.Lock()
+	previous, known := s.items[id]
+	s.remove(id)
+	s.mu.Unlock()
+
+	verdict, err := s.client.Recheck(ctx, c, RecheckReasonEdit)
+	if err != nil {
+		if known {
+			s.mu.Lock()
+			if _, replaced := s.items[id]; !replaced {
+				s.add(&recheckItem{id: id, comment: c, verdict: previous.verdict, due: s.now().Add(s.config.RetryDelay)})
+			}
+			s.mu.Unlock()
+		}
+		return verdict, err
+	}
+	if known {
+		s.reportChange(id, c, previous.verdict, verdict)
+	}
+	s.Schedule(id, c, verdict)
+	return verdict, nil
+}
+
+// Pending returns number of comments waiting for recheck.
+func (s *RecheckScheduler) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.items)
+}
+
+// Run processes due rechecks until context is done.
+func (s *RecheckScheduler) Run(ctx context.Context) error {
+	for {
+		s.mu.Lock()
+		var timer *time.Timer
+		var due <-chan time.Time
+		if len(s.queue) > 0 {
+			timer = time.NewTimer(s.queue[0].due.Sub(s.now()))
+			due = timer.C
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+		case <-s.updated:
+		case <-due:
+			s.recheckDue(ctx)
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *RecheckScheduler) recheckDue(ctx context.Context) {
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 || s.queue[0].due.After(s.now()) {
+			s.mu.Unlock()
+			return
+		}
+		item := heap.Pop(&s.queue).(*recheckItem)
+		delete(s.items, item.id)
+		s.mu.Unlock()
+
+		verdict, err := s.client.Recheck(ctx, item.comment, RecheckReasonQueue)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			if s.config.OnError != nil {
+				s.config.OnError(item.id, err)
+			}
+			s.mu.Lock()
+			if _, replaced := s.items[item.id]; !replaced {
+				item.due = s.now().Add(s.config.RetryDelay)
+				s.add(item)
+			}
+			s.mu.Unlock()
+			continue
+		}
+		s.reportChange(item.id, item.comment, item.verdict, verdict)
+		s.mu.Lock()
+		if _, replaced := s.items[item.id]; !replaced && verdict.RecheckAfter > 0 {
+			s.add(&recheckItem{id: item.id, comment: item.comment, verdict: verdict, due: s.now().Add(verdict.RecheckAfter)})
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *RecheckScheduler) reportChange(id string, c *Comment, previous, current Verdict) {
+	if previous.Spam != current.Spam && s.config.OnChange != nil {
+		s.config.OnChange(RecheckChange{ID: id, Comment: c, Previous: previous, Current: current})
+	}
+}
+
+// add and remove must be called with mutex held.
+func (s *RecheckScheduler) add(item *recheckItem) {
+	s.items[item.id] = item
+	heap.Push(&s.queue, item)
+	select {
+	case s.updated <- struct{}{}:
+	default:
+	}
+}
+
+func (s *RecheckScheduler) remove(id string) {
+	if item, ok := s.items[id]; ok {
+		heap.Remove(&s.queue, item.index)
+		delete(s.items, id)
+	}
+}
+
+// recheckQueue implements heap.Interface, ordering items by due time.
+type recheckQueue []*recheckItem
+
+func (q recheckQueue) Len() int           { return len(q) }
+func (q recheckQueue) Less(i, j int) bool { return q[i].due.Before(q[j].due) }
+func (q recheckQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *recheckQueue) Push(x interface{}) {
+	item := x.(*recheckItem)
+	item.index = len(*q)
+	*q = append(*q, item)
+}
+
+func (q *recheckQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
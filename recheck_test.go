@@ -0,0 +1,255 @@
+package akismet
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAkismetRecheck(t *testing.T) {
+	var recheckReason string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("got error parsing request form: '%v'", err)
+		}
+		recheckReason = r.PostForm.Get("recheck_reason")
+		w.Header().Set("X-akismet-recheck-after", "3600")
+		fmt.Fprint(w, "false")
+	}))
+	defer ts.Close()
+
+	cli := &akismetClient{
+		key:        "deadbeef",
+		blogUrl:    "http://some-blog.com",
+		httpClient: &http.Client{},
+		akismetUrl: ts.URL + "/%s/%s",
+	}
+	comment := &Comment{UserIP: "0.0.0.0", UserAgent: "Mozilla/6.16"}
+	verdict, err := cli.Recheck(context.Background(), comment, RecheckReasonEdit)
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got '%v'", err)
+	}
+	if recheckReason != "edit" {
+		t.Errorf("Expected recheck reason to be 'edit', but got '%s'", recheckReason)
+	}
+	if verdict.RecheckAfter != time.Hour {
+		t.Errorf("Expected recheck after to be '%v', but got '%v'", time.Hour, verdict.RecheckAfter)
+	}
+	if comment.RecheckReason != "" {
+		t.Errorf("Expected comment not to be modified, but got recheck reason '%s'", comment.RecheckReason)
+	}
+}
+
+func TestRecheckScheduler(t *testing.T) {
+	type response struct {
+		statusCode   int
+		body         string
+		recheckAfter string
+	}
+	tests := []struct {
+		name         string
+		responses    map[string][]response
+		run          func(ctx context.Context, s *RecheckScheduler)
+		expReasons   []string
+		expChanges   []bool
+		expErrors    int
+		expPending   int
+		waitRequests int
+	}{{
+		name: "recheck from queue reports changed verdict",
+		responses: map[string][]response{
+			"recheck_queue": {{body: "true"}},
+		},
+		run: func(ctx context.Context, s *RecheckScheduler) {
+			comment := &Comment{UserIP: "0.0.0.0", UserAgent: "Mozilla/6.16"}
+			s.Schedule("1", comment, Verdict{RecheckAfter: 10 * time.Millisecond})
+		},
+		expReasons:   []string{"recheck_queue"},
+		expChanges:   []bool{true},
+		waitRequests: 1,
+	}, {
+		name: "recheck advised again is rescheduled without reporting unchanged verdict",
+		responses: map[string][]response{
+			"":              {{body: "false", recheckAfter: "3600"}},
+			"recheck_queue": {{body: "false", recheckAfter: "3600"}},
+		},
+		run: func(ctx context.Context, s *RecheckScheduler) {
+			if _, err := s.Check(ctx, "1", &Comment{UserIP: "0.0.0.0", UserAgent: "Mozilla/6.16"}); err != nil {
+				t.Errorf("Expected error to be nil, but got '%v'", err)
+			}
+		},
+		expReasons:   []string{""},
+		expPending:   1,
+		waitRequests: 1,
+	}, {
+		name: "failed recheck is retried",
+		responses: map[string][]response{
+			"recheck_queue": {{statusCode: 500}, {body: "true"}},
+		},
+		run: func(ctx context.Context, s *RecheckScheduler) {
+			comment := &Comment{UserIP: "0.0.0.0", UserAgent: "Mozilla/6.16"}
+			s.Schedule("1", comment, Verdict{RecheckAfter: time.Millisecond})
+		},
+		expReasons:   []string{"recheck_queue", "recheck_queue"},
+		expChanges:   []bool{true},
+		expErrors:    1,
+		waitRequests: 2,
+	}, {
+		name: "edited comment is rechecked immediately",
+		responses: map[string][]response{
+			"edit": {{body: "false"}},
+		},
+		run: func(ctx context.Context, s *RecheckScheduler) {
+			comment := &Comment{UserIP: "0.0.0.0", UserAgent: "Mozilla/6.16"}
+			s.Schedule("1", comment, Verdict{Spam: true, RecheckAfter: time.Hour})
+			if _, err := s.Edited(ctx, "1", comment); err != nil {
+				t.Errorf("Expected error to be nil, but got '%v'", err)
+			}
+		},
+		expReasons:   []string{"edit"},
+		expChanges:   []bool{false},
+		waitRequests: 1,
+	}, {
+		name: "failed recheck of edited comment is retried",
+		responses: map[string][]response{
+			"edit":          {{statusCode: 500}},
+			"recheck_queue": {{body: "true"}},
+		},
+		run: func(ctx context.Context, s *RecheckScheduler) {
+			comment := &Comment{UserIP: "0.0.0.0", UserAgent: "Mozilla/6.16"}
+			s.Schedule("1", comment, Verdict{RecheckAfter: time.Hour})
+			if _, err := s.Edited(ctx, "1", comment); err == nil {
+				t.Error("Expected error, but got nil")
+			}
+		},
+		expReasons:   []string{"edit", "recheck_queue"},
+		expChanges:   []bool{true},
+		waitRequests: 2,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mu := &sync.Mutex{}
+			reasons := []string{}
+			requests := make(chan struct{}, 10)
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseForm(); err != nil {
+					t.Fatalf("got error parsing request form: '%v'", err)
+				}
+				reason := r.PostForm.Get("recheck_reason")
+				mu.Lock()
+				reasons = append(reasons, reason)
+				resp := tt.responses[reason][0]
+				tt.responses[reason] = tt.responses[reason][1:]
+				mu.Unlock()
+				if resp.recheckAfter != "" {
+					w.Header().Set("X-akismet-recheck-after", resp.recheckAfter)
+				}
+				if resp.statusCode != 0 {
+					w.WriteHeader(resp.statusCode)
+				}
+				fmt.Fprint(w, resp.body)
+				requests <- struct{}{}
+			}))
+			defer ts.Close()
+
+			cli := &akismetClient{
+				key:        "deadbeef",
+				blogUrl:    "http://some-blog.com",
+				httpClient: &http.Client{},
+				akismetUrl: ts.URL + "/%s/%s",
+			}
+			var changes []bool
+			errs := 0
+			scheduler := NewRecheckScheduler(cli, RecheckConfig{
+				OnChange: func(change RecheckChange) {
+					mu.Lock()
+					changes = append(changes, change.Current.Spam)
+					mu.Unlock()
+				},
+				OnError: func(string, error) {
+					mu.Lock()
+					errs++
+					mu.Unlock()
+				},
+				RetryDelay: time.Millisecond,
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error)
+			go func() { done <- scheduler.Run(ctx) }()
+			tt.run(ctx, scheduler)
+			for i := 0; i < tt.waitRequests; i++ {
+				select {
+				case <-requests:
+				case <-time.After(time.Second):
+					t.Fatalf("timed out waiting for request %d", i+1)
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+			if err := <-done; err != context.Canceled {
+				t.Errorf("Expected error to be '%v', but got '%v'", context.Canceled, err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if !reflect.DeepEqual(tt.expReasons, reasons) {
+				t.Errorf("Expected recheck reasons to be '%v', but got '%v'", tt.expReasons, reasons)
+			}
+			if !reflect.DeepEqual(tt.expChanges, changes) {
+				t.Errorf("Expected changes to be '%v', but got '%v'", tt.expChanges, changes)
+			}
+			if errs != tt.expErrors {
+				t.Errorf("Expected number of errors to be %d, but got %d", tt.expErrors, errs)
+			}
+			if pending := scheduler.Pending(); pending != tt.expPending {
+				t.Errorf("Expected number of pending rechecks to be %d, but got %d", tt.expPending, pending)
+			}
+		})
+	}
+}
+
+var _ Rechecker = &akismetClient{}
+
+type recheckerMock struct {
+	verdict Verdict
+	reasons []string
+}
+
+func (m *recheckerMock) CheckVerdict(context.Context, *Comment, ...CallOpt) (Verdict, error) {
+	return m.verdict, nil
+}
+
+func (m *recheckerMock) Recheck(_ context.Context, _ *Comment, reason string, _ ...CallOpt) (Verdict, error) {
+	m.reasons = append(m.reasons, reason)
+	return Verdict{Spam: true}, nil
+}
+
+func TestRecheckSchedulerWithMock(t *testing.T) {
+	mock := &recheckerMock{verdict: Verdict{RecheckAfter: time.Hour}}
+	var changes []RecheckChange
+	scheduler := NewRecheckScheduler(mock, RecheckConfig{
+		OnChange: func(change RecheckChange) { changes = append(changes, change) },
+	})
+	comment := &Comment{UserIP: "0.0.0.0", UserAgent: "Mozilla/6.16"}
+	if _, err := scheduler.Check(context.Background(), "1", comment); err != nil {
+		t.Fatalf("Expected error to be nil, but got '%v'", err)
+	}
+	if scheduler.Pending() != 1 {
+		t.Errorf("Expected comment to be scheduled, but got %d pending", scheduler.Pending())
+	}
+	if _, err := scheduler.Edited(context.Background(), "1", comment); err != nil {
+		t.Fatalf("Expected error to be nil, but got '%v'", err)
+	}
+	if !reflect.DeepEqual([]string{RecheckReasonEdit}, mock.reasons) {
+		t.Errorf("Expected edit recheck, but got '%v'", mock.reasons)
+	}
+	if len(changes) != 1 || !changes[0].Current.Spam {
+		t.Errorf("Expected verdict change to be reported, but got '%+v'", changes)
+	}
+}
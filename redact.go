@@ -0,0 +1,141 @@
+package akismet
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	stderr "errors"
+	"net"
+	"net/url"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// ErrRequiredParamRedacted is returned when redaction policy removes parameter required by Akismet.
+var ErrRequiredParamRedacted = stderr.New("redaction removed required parameter")
+
+// requiredParams are parameters Akismet requires in every comment, they cannot be removed by redaction.
+var requiredParams = []string{"user_ip", "user_agent"}
+
+// Redactor transforms value of a single parameter before it's sent to Akismet, empty result removes the parameter.
+// Removing user_ip or user_agent is an error, as they are required by Akismet.
+type Redactor func(value string) string
+
+// RedactionPolicy maps names of Akismet's parameters (the same as in Comment's struct tags) to redactors applied to
+// their values.
+type RedactionPolicy map[string]Redactor
+
+// RedactionReport describes parameters that were sent to Akismet after redaction, including blog and changes made by
+// interceptors.
+type RedactionReport struct {
+	Endpoint string
+	Sent     url.Values
+	// Redacted holds sorted names of parameters changed or removed by redaction policy.
+	Redacted []string
+}
+
+// WithRedaction is client functional option to set redaction policy applied to each comment before it's sent, report
+// function, if not nil, is called with parameters that were actually sent.
+func WithRedaction(policy RedactionPolicy, report func(RedactionReport)) OptFn {
+	return func(c *akismetClient) {
+		c.redaction = policy
+		c.redactionReport = report
+	}
+}
+
+// apply redacts payload in place and returns names of changed parameters.
+func (p RedactionPolicy) apply(payload *url.Values) ([]string, error) {
+	redacted := []string{}
+	for name, redactor := range p {
+		values, ok := (*payload)[name]
+		if !ok {
+			continue
+		}
+		changed := false
+		result := make([]string, 0, len(values))
+		for _, value := range values {
+			newValue := redactor(value)
+			if newValue != value {
+				changed = true
+			}
+			if newValue != "" {
+				result = append(result, newValue)
+			}
+		}
+		if !changed {
+			continue
+		}
+		redacted = append(redacted, name)
+		if len(result) == 0 {
+			payload.Del(name)
+		} else {
+			(*payload)[name] = result
+		}
+	}
+	for _, name := range requiredParams {
+		if payload.Get(name) == "" {
+			return nil, errors.Wrapf(ErrRequiredParamRedacted, "parameter '%s'", name)
+		}
+	}
+	sort.Strings(redacted)
+	return redacted, nil
+}
+
+// TruncateIP returns redactor that zeroes host part of IP address, leaving /24 network for IPv4 and /48 for IPv6.
+// Values that are not valid IP addresses are left unchanged.
+func TruncateIP() Redactor {
+	return func(value string) string {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return value
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			return ip4.Mask(net.CIDRMask(24, 32)).String()
+		}
+		return ip.Mask(net.CIDRMask(48, 128)).String()
+	}
+}
+
+// HashValue returns redactor replacing value with hex encoded SHA-256 of salt and value, so the same values can still
+// be correlated by Akismet without disclosing them.
+func HashValue(salt string) Redactor {
+	return func(value string) string {
+		if value == "" {
+			return value
+		}
+		sum := sha256.Sum256([]byte(salt + value))
+		return hex.EncodeToString(sum[:])
+	}
+}
+
+// Drop returns redactor which removes parameter.
+func Drop() Redactor {
+	return func(string) string {
+		return ""
+	}
+}
+
+// StripQuery returns redactor removing query string and fragment from URL, values that cannot be parsed are removed.
+func StripQuery() Redactor {
+	return func(value string) string {
+		u, err := url.Parse(value)
+		if err != nil {
+			return ""
+		}
+		u.RawQuery = ""
+		u.ForceQuery = false
+		u.Fragment = ""
+		u.RawFragment = ""
+		return u.String()
+	}
+}
+
+// OmitLongerThan returns redactor which removes parameter if its value is longer than given number of bytes.
+func OmitLongerThan(size int) Redactor {
+	return func(value string) string {
+		if len(value) > size {
+			return ""
+		}
+		return value
+	}
+}
@@ -0,0 +1,162 @@
+package akismet
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestRedactors(t *testing.T) {
+	tests := []struct {
+		name     string
+		redactor Redactor
+		value    string
+		expected string
+	}{{
+		name:     "truncate IPv4",
+		redactor: TruncateIP(),
+		value:    "83.12.34.56",
+		expected: "83.12.34.0",
+	}, {
+		name:     "truncate IPv6",
+		redactor: TruncateIP(),
+		value:    "2001:db8:85a3:8d3:1319:8a2e:370:7348",
+		expected: "2001:db8:85a3::",
+	}, {
+		name:     "truncate leaves invalid IP unchanged",
+		redactor: TruncateIP(),
+		value:    "localhost",
+		expected: "localhost",
+	}, {
+		name:     "hash value with salt",
+		redactor: HashValue("pepper"),
+		value:    "john@doe.com",
+		expected: "399993f306cc39e6583bab156ec7836ac8fc623195a77564ace1e7241321f162",
+	}, {
+		name:     "hash leaves empty value",
+		redactor: HashValue("pepper"),
+		value:    "",
+		expected: "",
+	}, {
+		name:     "drop value",
+		redactor: Drop(),
+		value:    "john@doe.com",
+		expected: "",
+	}, {
+		name:     "strip query and fragment",
+		redactor: StripQuery(),
+		value:    "https://some-blog.com/post/1?token=secret#comments",
+		expected: "https://some-blog.com/post/1",
+	}, {
+		name:     "strip query removes unparsable URL",
+		redactor: StripQuery(),
+		value:    "%zz",
+		expected: "",
+	}, {
+		name:     "omit longer than size",
+		redactor: OmitLongerThan(4),
+		value:    "lorem ipsum",
+		expected: "",
+	}, {
+		name:     "keep not longer than size",
+		redactor: OmitLongerThan(5),
+		value:    "lorem",
+		expected: "lorem",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.redactor(tt.value)
+			if result != tt.expected {
+				t.Errorf("Expected redacted value to be '%s', but got '%s'", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestAkismetWithRedaction(t *testing.T) {
+	var payload []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		payload, _ = ioutil.ReadAll(r.Body)
+		fmt.Fprint(w, "false")
+	}))
+	defer ts.Close()
+
+	var reports []RedactionReport
+	cli, _ := NewAkismet("deadbeef", "http://some-blog.com", WithRedaction(RedactionPolicy{
+		"user_ip":              TruncateIP(),
+		"comment_author_email": Drop(),
+		"referrer":             StripQuery(),
+		"comment_content":      OmitLongerThan(5),
+		"comment_author":       TruncateIP(),
+	}, func(report RedactionReport) {
+		reports = append(reports, report)
+	}), WithInterceptors(func(ctx context.Context, call *Call, next Invoker) (*Response, error) {
+		call.Form.Set("comment_type", "comment")
+		return next(ctx, call)
+	}))
+	cli.akismetUrl = ts.URL + "/%s/%s"
+
+	_, err := cli.Check(context.Background(), &Comment{
+		UserIP:      "83.12.34.56",
+		UserAgent:   "Mozilla/6.16",
+		Referrer:    "https://google.com/?q=some+blog",
+		AuthorEmail: "john@doe.com",
+		Author:      "John Doe",
+		Content:     "lorem ipsum",
+	})
+	if err != nil {
+		t.Fatalf("Expected error to be nil, but got '%v'", err)
+	}
+
+	expPayload := "blog=http%3A%2F%2Fsome-blog.com&comment_author=John+Doe&comment_type=comment&referrer=https%3A%2F%2Fgoogle.com%2F&user_agent=Mozilla%2F6.16&user_ip=83.12.34.0"
+	if string(payload) != expPayload {
+		t.Errorf("Expected requst payload to be \n'%s', but got \n'%s'", expPayload, payload)
+	}
+	expReports := []RedactionReport{{
+		Endpoint: "comment-check",
+		Sent: url.Values{
+			"blog":           {"http://some-blog.com"},
+			"comment_author": {"John Doe"},
+			"comment_type":   {"comment"},
+			"referrer":       {"https://google.com/"},
+			"user_agent":     {"Mozilla/6.16"},
+			"user_ip":        {"83.12.34.0"},
+		},
+		Redacted: []string{"comment_author_email", "comment_content", "referrer", "user_ip"},
+	}}
+	if !reflect.DeepEqual(expReports, reports) {
+		t.Errorf("Expected reports to be '%+v', but got '%+v'", expReports, reports)
+	}
+	if strings.Contains(string(payload), "doe.com") {
+		t.Errorf("Expected author email to be dropped, but got '%s'", payload)
+	}
+}
+
+func TestAkismetRedactionOfRequiredParam(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Expected request not to be sent")
+	}))
+	defer ts.Close()
+
+	cli, _ := NewAkismet("deadbeef", "http://some-blog.com", WithRedaction(RedactionPolicy{
+		"user_agent": Drop(),
+	}, nil))
+	cli.akismetUrl = ts.URL + "/%s/%s"
+
+	_, err := cli.Check(context.Background(), &Comment{UserIP: "83.12.34.56", UserAgent: "Mozilla/6.16"})
+	if errors.Cause(err) != ErrRequiredParamRedacted {
+		t.Errorf("Expected error cause to be '%v', but got '%v'", ErrRequiredParamRedacted, err)
+	}
+	if err := cli.SubmitSpam(context.Background(), &Comment{UserIP: "83.12.34.56", UserAgent: "Mozilla/6.16"}); errors.Cause(err) != ErrRequiredParamRedacted {
+		t.Errorf("Expected error cause to be '%v', but got '%v'", ErrRequiredParamRedacted, err)
+	}
+}
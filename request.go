@@ -3,37 +3,124 @@ package akismet
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"sync"
 
 	"github.com/pkg/errors"
 )
 
-// ErrNonOKStatusCode returned when Akismet API returns non OK status, which shouldn't happen on normal usage.
-var ErrNonOKStatusCode = errors.New("akismet API returned non 200 status code")
+const (
+	// defaultMaxResponseSize is more than enough for any Akismet response, which are a few words long.
+	defaultMaxResponseSize = 64 << 10
+	// maxDrainSize is how much of unread body is discarded, so connection can be reused.
+	maxDrainSize = 64 << 10
+	// statusErrorBodySize is how much of body is kept in StatusError.
+	statusErrorBodySize = 512
+)
+
+var (
+	// ErrNonOKStatusCode returned when Akismet API returns non OK status, which shouldn't happen on normal usage.
+	ErrNonOKStatusCode = errors.New("akismet API returned non 200 status code")
+	// ErrResponseTooLarge returned when Akismet API response is larger than allowed size.
+	ErrResponseTooLarge = errors.New("akismet API response is too large")
+)
 
-func (a *akismetClient) post(ctx context.Context, url string, payload *url.Values) (string, error) {
-	payload.Add("blog", a.blogUrl)
+// StatusError is returned when Akismet API returns non OK status. It contains beginning of response body and headers,
+// which usually explain what went wrong. Its cause is ErrNonOKStatusCode.
+type StatusError struct {
+	StatusCode int
+	Body       string
+	Header     http.Header
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("got status code %d: %v", e.StatusCode, ErrNonOKStatusCode)
+	}
+	return fmt.Sprintf("got status code %d, body: %q: %v", e.StatusCode, e.Body, ErrNonOKStatusCode)
+}
+
+// Cause returns ErrNonOKStatusCode, so errors.Cause can be used to check for it.
+func (e *StatusError) Cause() error {
+	return ErrNonOKStatusCode
+}
+
+var bufferPool = sync.Pool{
+	New: func() interface{} { return &bytes.Buffer{} },
+}
+
+func (a *akismetClient) post(ctx context.Context, url string, payload url.Values, header http.Header) (string, http.Header, error) {
 	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBufferString(payload.Encode()))
 	if err != nil {
-		return "", errors.Wrap(err, "error creating HTTP request")
+		return "", nil, errors.Wrap(err, "error creating HTTP request")
 	}
 
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	for name, values := range header {
+		req.Header[name] = append([]string{}, values...)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	resp, err := a.httpClient.Do(req.WithContext(ctx))
 	if err != nil {
-		return "", errors.Wrap(err, "cannot do HTTP request")
+		return "", nil, errors.Wrap(err, "cannot do HTTP request")
 	}
+	defer drainAndClose(resp.Body)
+
+	// closing body unblocks read in progress when context is done
+	readDone := make(chan struct{})
+	defer close(readDone)
+	go func() {
+		select {
+		case <-ctx.Done():
+			resp.Body.Close()
+		case <-readDone:
+		}
+	}()
+	body := &ctxReader{ctx: ctx, r: resp.Body}
+
 	if resp.StatusCode != http.StatusOK {
-		return "", errors.Wrapf(ErrNonOKStatusCode, "got status code %d", resp.StatusCode)
+		snippet, _ := ioutil.ReadAll(io.LimitReader(body, statusErrorBodySize))
+		return "", nil, &StatusError{StatusCode: resp.StatusCode, Body: string(snippet), Header: resp.Header}
 	}
 
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return "", errors.Wrap(err, "can't read response body")
+	maxSize := a.maxResponseSize
+	if maxSize <= 0 {
+		maxSize = defaultMaxResponseSize
 	}
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufferPool.Put(buf)
+	if _, err := buf.ReadFrom(io.LimitReader(body, maxSize+1)); err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return "", nil, errors.Wrap(err, "can't read response body")
+	}
+	if int64(buf.Len()) > maxSize {
+		return "", nil, errors.Wrapf(ErrResponseTooLarge, "limit is %d bytes", maxSize)
+	}
+
+	return buf.String(), resp.Header, nil
+}
+
+// drainAndClose discards rest of body, up to maxDrainSize, and closes it, so underlying connection can be reused.
+func drainAndClose(body io.ReadCloser) {
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(body, maxDrainSize))
+	_ = body.Close()
+}
 
-	return string(body), nil
+// ctxReader stops reading once context is done.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
 }
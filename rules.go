@@ -0,0 +1,183 @@
+package akismet
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// RuleDecision is a decision made locally by Rule.
+type RuleDecision int
+
+const (
+	// RuleUndecided means that rule has no opinion about comment, and next rules (or Akismet) should decide.
+	RuleUndecided RuleDecision = iota
+	// RuleSpam means that comment is spam.
+	RuleSpam
+	// RuleHam means that comment is not spam.
+	RuleHam
+)
+
+// RuleResult is a result of Rule evaluation, reason describes why the decision was made.
+type RuleResult struct {
+	Decision RuleDecision
+	Reason   string
+}
+
+// Rule examines comment locally, without calling Akismet.
+type Rule func(c *Comment) RuleResult
+
+var linkRegexp = regexp.MustCompile(`(?i)https?://`)
+
+// WithPreFilter is client functional option to set rules evaluated before comment is checked. First decisive rule
+// short-circuits the check, Akismet is called only when all rules are undecided.
+func WithPreFilter(rules ...Rule) OptFn {
+	return func(c *akismetClient) {
+		c.preFilter = rules
+	}
+}
+
+// EvaluateRules runs rules in order and returns result of the first decisive one.
+func EvaluateRules(c *Comment, rules ...Rule) RuleResult {
+	for _, rule := range rules {
+		if result := rule(c); result.Decision != RuleUndecided {
+			return result
+		}
+	}
+	return RuleResult{}
+}
+
+// BlockIPs returns rule marking comments sent from given IP addresses or CIDR networks as spam.
+func BlockIPs(cidrs ...string) (Rule, error) {
+	networks, err := parseNetworks(cidrs)
+	if err != nil {
+		return nil, err
+	}
+	return ipRule(networks, RuleSpam, "blocked IP"), nil
+}
+
+// AllowIPs returns rule marking comments sent from given IP addresses or CIDR networks as ham.
+func AllowIPs(cidrs ...string) (Rule, error) {
+	networks, err := parseNetworks(cidrs)
+	if err != nil {
+		return nil, err
+	}
+	return ipRule(networks, RuleHam, "allowed IP"), nil
+}
+
+// BannedWords returns rule marking comments with author or content matching any of given regular expressions as
+// spam. Expressions are case insensitive.
+func BannedWords(patterns ...string) (Rule, error) {
+	regexps := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot compile pattern '%s'", pattern)
+		}
+		regexps = append(regexps, re)
+	}
+	return func(c *Comment) RuleResult {
+		for i, re := range regexps {
+			if re.MatchString(c.Author) || re.MatchString(c.Content) {
+				return RuleResult{Decision: RuleSpam, Reason: fmt.Sprintf("banned word matching '%s'", patterns[i])}
+			}
+		}
+		return RuleResult{}
+	}, nil
+}
+
+// MaxLinks returns rule marking comments with content containing more than given number of links as spam.
+func MaxLinks(max int) Rule {
+	return func(c *Comment) RuleResult {
+		if links := len(linkRegexp.FindAllStringIndex(c.Content, -1)); links > max {
+			return RuleResult{Decision: RuleSpam, Reason: fmt.Sprintf("too many links (%d > %d)", links, max)}
+		}
+		return RuleResult{}
+	}
+}
+
+// ContentLength returns rule marking comments with content shorter than min or longer than max characters as spam.
+// Zero disables given limit.
+func ContentLength(min, max int) Rule {
+	return func(c *Comment) RuleResult {
+		length := utf8.RuneCountInString(c.Content)
+		if min > 0 && length < min {
+			return RuleResult{Decision: RuleSpam, Reason: fmt.Sprintf("content too short (%d < %d)", length, min)}
+		}
+		if max > 0 && length > max {
+			return RuleResult{Decision: RuleSpam, Reason: fmt.Sprintf("content too long (%d > %d)", length, max)}
+		}
+		return RuleResult{}
+	}
+}
+
+// BlockEmailDomains returns rule marking comments with author email in given domains (or their subdomains) as spam.
+func BlockEmailDomains(domains ...string) Rule {
+	return emailDomainRule(domains, RuleSpam, "blocked email domain")
+}
+
+// AllowEmailDomains returns rule marking comments with author email in given domains (or their subdomains) as ham.
+func AllowEmailDomains(domains ...string) Rule {
+	return emailDomainRule(domains, RuleHam, "allowed email domain")
+}
+
+func ipRule(networks []*net.IPNet, decision RuleDecision, reason string) Rule {
+	return func(c *Comment) RuleResult {
+		ip := net.ParseIP(c.UserIP)
+		if ip == nil {
+			return RuleResult{}
+		}
+		for _, network := range networks {
+			if network.Contains(ip) {
+				return RuleResult{Decision: decision, Reason: fmt.Sprintf("%s in %s", reason, network)}
+			}
+		}
+		return RuleResult{}
+	}
+}
+
+func emailDomainRule(domains []string, decision RuleDecision, reason string) Rule {
+	return func(c *Comment) RuleResult {
+		at := strings.LastIndex(c.AuthorEmail, "@")
+		if at < 0 {
+			return RuleResult{}
+		}
+		emailDomain := strings.ToLower(c.AuthorEmail[at+1:])
+		for _, domain := range domains {
+			domain = strings.ToLower(domain)
+			if emailDomain == domain || strings.HasSuffix(emailDomain, "."+domain) {
+				return RuleResult{Decision: decision, Reason: fmt.Sprintf("%s %s", reason, domain)}
+			}
+		}
+		return RuleResult{}
+	}
+}
+
+// parseNetworks parses CIDR networks, single IP addresses are treated as networks containing only them.
+func parseNetworks(cidrs []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, errors.Errorf("invalid IP address '%s'", cidr)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot parse network '%s'", cidr)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
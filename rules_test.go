@@ -0,0 +1,149 @@
+package akismet
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestRules(t *testing.T) {
+	mustRule := func(rule Rule, err error) Rule {
+		if err != nil {
+			t.Fatalf("got error creating rule: '%v'", err)
+		}
+		return rule
+	}
+
+	tests := []struct {
+		name     string
+		rules    []Rule
+		comment  *Comment
+		expected RuleResult
+	}{{
+		name:     "undecided without rules",
+		comment:  &Comment{UserIP: "1.2.3.4"},
+		expected: RuleResult{},
+	}, {
+		name:     "spam from blocked network",
+		rules:    []Rule{mustRule(BlockIPs("10.0.0.0/8", "1.2.3.4"))},
+		comment:  &Comment{UserIP: "10.1.2.3"},
+		expected: RuleResult{Decision: RuleSpam, Reason: "blocked IP in 10.0.0.0/8"},
+	}, {
+		name:     "spam from blocked IP",
+		rules:    []Rule{mustRule(BlockIPs("10.0.0.0/8", "1.2.3.4", "2001:db8::1"))},
+		comment:  &Comment{UserIP: "2001:db8::1"},
+		expected: RuleResult{Decision: RuleSpam, Reason: "blocked IP in 2001:db8::1/128"},
+	}, {
+		name:     "allowed IP takes precedence when evaluated first",
+		rules:    []Rule{mustRule(AllowIPs("1.2.3.4")), mustRule(BlockIPs("1.2.3.0/24"))},
+		comment:  &Comment{UserIP: "1.2.3.4"},
+		expected: RuleResult{Decision: RuleHam, Reason: "allowed IP in 1.2.3.4/32"},
+	}, {
+		name:     "spam with banned word in content",
+		rules:    []Rule{mustRule(BannedWords(`\bviagra\b`, "casino"))},
+		comment:  &Comment{Content: "Cheap VIAGRA here"},
+		expected: RuleResult{Decision: RuleSpam, Reason: `banned word matching '\bviagra\b'`},
+	}, {
+		name:     "spam with banned word in author",
+		rules:    []Rule{mustRule(BannedWords(`\bviagra\b`, "casino"))},
+		comment:  &Comment{Author: "Best Casino"},
+		expected: RuleResult{Decision: RuleSpam, Reason: "banned word matching 'casino'"},
+	}, {
+		name:     "spam with too many links",
+		rules:    []Rule{MaxLinks(1)},
+		comment:  &Comment{Content: "see http://a.com and HTTPS://b.com"},
+		expected: RuleResult{Decision: RuleSpam, Reason: "too many links (2 > 1)"},
+	}, {
+		name:     "undecided with links within limit",
+		rules:    []Rule{MaxLinks(1)},
+		comment:  &Comment{Content: "see http://a.com"},
+		expected: RuleResult{},
+	}, {
+		name:     "spam with too short content",
+		rules:    []Rule{ContentLength(3, 0)},
+		comment:  &Comment{Content: "ok"},
+		expected: RuleResult{Decision: RuleSpam, Reason: "content too short (2 < 3)"},
+	}, {
+		name:     "spam with too long content",
+		rules:    []Rule{ContentLength(0, 3)},
+		comment:  &Comment{Content: "żółw"},
+		expected: RuleResult{Decision: RuleSpam, Reason: "content too long (4 > 3)"},
+	}, {
+		name:     "spam from blocked email subdomain",
+		rules:    []Rule{BlockEmailDomains("spam.com")},
+		comment:  &Comment{AuthorEmail: "john@mail.SPAM.com"},
+		expected: RuleResult{Decision: RuleSpam, Reason: "blocked email domain spam.com"},
+	}, {
+		name:     "undecided for similar email domain",
+		rules:    []Rule{BlockEmailDomains("spam.com")},
+		comment:  &Comment{AuthorEmail: "john@notspam.com"},
+		expected: RuleResult{},
+	}, {
+		name:     "ham from allowed email domain",
+		rules:    []Rule{AllowEmailDomains("some-blog.com")},
+		comment:  &Comment{AuthorEmail: "editor@some-blog.com"},
+		expected: RuleResult{Decision: RuleHam, Reason: "allowed email domain some-blog.com"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := EvaluateRules(tt.comment, tt.rules...)
+			if !reflect.DeepEqual(tt.expected, result) {
+				t.Errorf("Expected result to be '%+v', but got '%+v'", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestRuleErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		expMsg string
+	}{{
+		name:   "invalid IP",
+		err:    func() error { _, err := BlockIPs("1.2.3"); return err }(),
+		expMsg: "invalid IP address '1.2.3'",
+	}, {
+		name:   "invalid network",
+		err:    func() error { _, err := AllowIPs("1.2.3.4/33"); return err }(),
+		expMsg: "cannot parse network '1.2.3.4/33': invalid CIDR address: 1.2.3.4/33",
+	}, {
+		name:   "invalid pattern",
+		err:    func() error { _, err := BannedWords("("); return err }(),
+		expMsg: "cannot compile pattern '(': error parsing regexp: missing closing ): `(?i)(`",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.err == nil || tt.err.Error() != tt.expMsg {
+				t.Errorf("Expected error to be '%s', but got '%v'", tt.expMsg, tt.err)
+			}
+		})
+	}
+}
+
+func TestAkismetWithPreFilter(t *testing.T) {
+	transport := &transportMock{roundTripErr: errors.New("mocked error from transport")}
+	cli, _ := NewAkismet("deadbeef", "http://some-blog.com",
+		WithHttpClient(&http.Client{Transport: transport}),
+		WithPreFilter(MaxLinks(0)),
+	)
+	verdict, err := cli.CheckVerdict(context.Background(), &Comment{
+		UserIP:    "1.2.3.4",
+		UserAgent: "Mozilla/6.16",
+		Content:   "http://spam.com",
+	})
+	if err != nil {
+		t.Errorf("Expected error to be nil, but got '%v'", err)
+	}
+	if exp := (Verdict{Spam: true, Reason: "too many links (1 > 0)"}); !reflect.DeepEqual(exp, verdict) {
+		t.Errorf("Expected verdict to be '%+v', but got '%+v'", exp, verdict)
+	}
+
+	_, err = cli.CheckVerdict(context.Background(), &Comment{UserIP: "1.2.3.4", UserAgent: "Mozilla/6.16"})
+	if err == nil {
+		t.Errorf("Expected undecided comment to be checked by Akismet, but got no error from mocked transport")
+	}
+}
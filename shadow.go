@@ -0,0 +1,185 @@
+package akismet
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ShadowDisagreement describes comment for which primary and shadow checkers gave different verdicts.
+type ShadowDisagreement struct {
+	Comment *Comment
+	Primary Verdict
+	Shadow  Verdict
+}
+
+// LatencyStats summarizes duration of checks.
+type LatencyStats struct {
+	Count int
+	Total time.Duration
+	Min   time.Duration
+	Max   time.Duration
+}
+
+// Mean returns average duration of checks.
+func (s LatencyStats) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Count)
+}
+
+func (s *LatencyStats) add(d time.Duration) {
+	if s.Count == 0 || d < s.Min {
+		s.Min = d
+	}
+	if d > s.Max {
+		s.Max = d
+	}
+	s.Count++
+	s.Total += d
+}
+
+// ShadowStats holds comparison of primary and shadow checkers. Only comments checked without error, and with decisive
+// verdict, by both of them are compared.
+type ShadowStats struct {
+	Compared      int
+	Agreements    int
+	Disagreements int
+	// PrimarySpam is number of disagreements where primary checker found spam and shadow didn't.
+	PrimarySpam int
+	// ShadowSpam is number of disagreements where shadow checker found spam and primary didn't.
+	ShadowSpam    int
+	PrimaryErrors int
+	ShadowErrors  int
+	// PrimaryUndecided and ShadowUndecided are numbers of undecided verdicts, see Verdict.Undecided.
+	PrimaryUndecided int
+	ShadowUndecided  int
+
+	PrimaryLatency LatencyStats
+	ShadowLatency  LatencyStats
+}
+
+// AgreementRate returns fraction of compared comments for which both checkers agreed.
+func (s ShadowStats) AgreementRate() float64 {
+	if s.Compared == 0 {
+		return 0
+	}
+	return float64(s.Agreements) / float64(s.Compared)
+}
+
+// ShadowConfig configures ShadowEvaluator.
+type ShadowConfig struct {
+	// OnDisagreement is called for each comment checkers disagree about.
+	OnDisagreement func(d ShadowDisagreement)
+	// OnShadowError is called with errors of shadow checker, which are otherwise ignored.
+	OnShadowError func(c *Comment, err error)
+	// ShadowTimeout limits time of shadow check, defaults to 10 seconds.
+	ShadowTimeout time.Duration
+}
+
+// ShadowEvaluator runs primary checker (i.e. existing filter) and shadow one (i.e. Akismet) on each comment,
+// returning primary verdict, and records how often they agree, so shadow can be evaluated before switching to it.
+type ShadowEvaluator struct {
+	primary Checker
+	shadow  Checker
+	config  ShadowConfig
+
+	mu       sync.Mutex
+	stats    ShadowStats
+	inFlight sync.WaitGroup
+}
+
+// NewShadowEvaluator returns evaluator of shadow checker against the primary one.
+func NewShadowEvaluator(primary, shadow Checker, config ShadowConfig) *ShadowEvaluator {
+	if config.ShadowTimeout <= 0 {
+		config.ShadowTimeout = defaultTimeout
+	}
+	return &ShadowEvaluator{
+		primary: primary,
+		shadow:  shadow,
+		config:  config,
+	}
+}
+
+// CheckVerdict runs both checkers concurrently and returns verdict and error of the primary one, as soon as it's
+// known. Shadow check runs in background, detached from ctx cancellation and limited by ShadowTimeout, so it doesn't
+// affect latency of the primary one. Comment must not be modified until shadow check is done, see Wait.
+func (s *ShadowEvaluator) CheckVerdict(ctx context.Context, c *Comment, opts ...CallOpt) (Verdict, error) {
+	primaryDone := make(chan checkResult, 1)
+	s.inFlight.Add(1)
+	go s.shadowCheck(context.WithoutCancel(ctx), c, opts, primaryDone)
+
+	start := time.Now()
+	verdict, err := s.primary.CheckVerdict(ctx, c, opts...)
+	primaryTook := time.Since(start)
+
+	s.mu.Lock()
+	s.stats.PrimaryLatency.add(primaryTook)
+	if err != nil {
+		s.stats.PrimaryErrors++
+	} else if verdict.Undecided {
+		s.stats.PrimaryUndecided++
+	}
+	s.mu.Unlock()
+
+	primaryDone <- checkResult{verdict: verdict, err: err}
+	return verdict, err
+}
+
+// Wait waits until all shadow checks in progress are done, i.e. before reading final stats.
+func (s *ShadowEvaluator) Wait() {
+	s.inFlight.Wait()
+}
+
+// shadowCheck runs shadow checker and compares its verdict with the primary one, once it's known.
+func (s *ShadowEvaluator) shadowCheck(ctx context.Context, c *Comment, opts []CallOpt, primaryDone <-chan checkResult) {
+	defer s.inFlight.Done()
+	ctx, cancel := context.WithTimeout(ctx, s.config.ShadowTimeout)
+	defer cancel()
+
+	start := time.Now()
+	shadowVerdict, shadowErr := s.shadow.CheckVerdict(ctx, c, opts...)
+	shadowTook := time.Since(start)
+	primary := <-primaryDone
+	verdict, err := primary.verdict, primary.err
+
+	s.mu.Lock()
+	s.stats.ShadowLatency.add(shadowTook)
+	if shadowErr != nil {
+		s.stats.ShadowErrors++
+	} else if shadowVerdict.Undecided {
+		s.stats.ShadowUndecided++
+	}
+	disagree := false
+	if err == nil && shadowErr == nil && !verdict.Undecided && !shadowVerdict.Undecided {
+		s.stats.Compared++
+		switch {
+		case verdict.Spam == shadowVerdict.Spam:
+			s.stats.Agreements++
+		case verdict.Spam:
+			s.stats.Disagreements++
+			s.stats.PrimarySpam++
+			disagree = true
+		default:
+			s.stats.Disagreements++
+			s.stats.ShadowSpam++
+			disagree = true
+		}
+	}
+	s.mu.Unlock()
+
+	if shadowErr != nil && s.config.OnShadowError != nil {
+		s.config.OnShadowError(c, shadowErr)
+	}
+	if disagree && s.config.OnDisagreement != nil {
+		s.config.OnDisagreement(ShadowDisagreement{Comment: c, Primary: verdict, Shadow: shadowVerdict})
+	}
+}
+
+// Stats returns current comparison stats.
+func (s *ShadowEvaluator) Stats() ShadowStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
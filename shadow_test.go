@@ -0,0 +1,190 @@
+package akismet
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var _ Checker = &akismetClient{}
+
+func staticChecker(verdict Verdict, err error) Checker {
+	return CheckerFunc(func(context.Context, *Comment, ...CallOpt) (Verdict, error) {
+		return verdict, err
+	})
+}
+
+func TestShadowEvaluator(t *testing.T) {
+	checkErr := errors.New("check error")
+	comment := &Comment{UserIP: "0.0.0.0", UserAgent: "Mozilla/6.16"}
+
+	tests := []struct {
+		name             string
+		primary          Checker
+		shadow           Checker
+		expVerdict       Verdict
+		expErr           error
+		expStats         ShadowStats
+		expDisagreements []ShadowDisagreement
+		expShadowErrors  int
+	}{{
+		name:       "agreement",
+		primary:    staticChecker(Verdict{Spam: true, Reason: "primary"}, nil),
+		shadow:     staticChecker(Verdict{Spam: true, GUID: "guid"}, nil),
+		expVerdict: Verdict{Spam: true, Reason: "primary"},
+		expStats:   ShadowStats{Compared: 1, Agreements: 1},
+	}, {
+		name:       "primary found spam",
+		primary:    staticChecker(Verdict{Spam: true}, nil),
+		shadow:     staticChecker(Verdict{GUID: "guid"}, nil),
+		expVerdict: Verdict{Spam: true},
+		expStats:   ShadowStats{Compared: 1, Disagreements: 1, PrimarySpam: 1},
+		expDisagreements: []ShadowDisagreement{{
+			Comment: comment, Primary: Verdict{Spam: true}, Shadow: Verdict{GUID: "guid"},
+		}},
+	}, {
+		name:       "shadow found spam",
+		primary:    staticChecker(Verdict{}, nil),
+		shadow:     staticChecker(Verdict{Spam: true}, nil),
+		expVerdict: Verdict{},
+		expStats:   ShadowStats{Compared: 1, Disagreements: 1, ShadowSpam: 1},
+		expDisagreements: []ShadowDisagreement{{
+			Comment: comment, Primary: Verdict{}, Shadow: Verdict{Spam: true},
+		}},
+	}, {
+		name:            "shadow error doesn't affect verdict",
+		primary:         staticChecker(Verdict{}, nil),
+		shadow:          staticChecker(Verdict{Spam: true}, checkErr),
+		expVerdict:      Verdict{},
+		expStats:        ShadowStats{ShadowErrors: 1},
+		expShadowErrors: 1,
+	}, {
+		name:       "primary error is returned",
+		primary:    staticChecker(Verdict{Spam: true}, checkErr),
+		shadow:     staticChecker(Verdict{}, nil),
+		expVerdict: Verdict{Spam: true},
+		expErr:     checkErr,
+		expStats:   ShadowStats{PrimaryErrors: 1},
+	}, {
+		name:       "undecided primary is not compared",
+		primary:    staticChecker(Verdict{Undecided: true}, nil),
+		shadow:     staticChecker(Verdict{Spam: true}, nil),
+		expVerdict: Verdict{Undecided: true},
+		expStats:   ShadowStats{PrimaryUndecided: 1},
+	}, {
+		name:       "undecided shadow is not compared",
+		primary:    staticChecker(Verdict{}, nil),
+		shadow:     staticChecker(Verdict{Undecided: true}, nil),
+		expVerdict: Verdict{},
+		expStats:   ShadowStats{ShadowUndecided: 1},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				disagreements []ShadowDisagreement
+				shadowErrors  int
+			)
+			evaluator := NewShadowEvaluator(tt.primary, tt.shadow, ShadowConfig{
+				OnDisagreement: func(d ShadowDisagreement) { disagreements = append(disagreements, d) },
+				OnShadowError:  func(*Comment, error) { shadowErrors++ },
+			})
+			verdict, err := evaluator.CheckVerdict(context.Background(), comment)
+			if !reflect.DeepEqual(tt.expVerdict, verdict) {
+				t.Errorf("Expected verdict to be '%#v', but got '%#v'", tt.expVerdict, verdict)
+			}
+			if err != tt.expErr {
+				t.Errorf("Expected error to be '%v', but got '%v'", tt.expErr, err)
+			}
+			evaluator.Wait()
+			stats := evaluator.Stats()
+			if stats.PrimaryLatency.Count != 1 || stats.ShadowLatency.Count != 1 {
+				t.Errorf("Expected single latency sample of each checker, but got '%+v'", stats)
+			}
+			stats.PrimaryLatency, stats.ShadowLatency = LatencyStats{}, LatencyStats{}
+			if !reflect.DeepEqual(tt.expStats, stats) {
+				t.Errorf("Expected stats to be '%+v', but got '%+v'", tt.expStats, stats)
+			}
+			if !reflect.DeepEqual(tt.expDisagreements, disagreements) {
+				t.Errorf("Expected disagreements to be '%+v', but got '%+v'", tt.expDisagreements, disagreements)
+			}
+			if shadowErrors != tt.expShadowErrors {
+				t.Errorf("Expected %d shadow errors, but got %d", tt.expShadowErrors, shadowErrors)
+			}
+		})
+	}
+}
+
+func TestShadowEvaluatorConcurrentChecks(t *testing.T) {
+	slow := func(d time.Duration) Checker {
+		return CheckerFunc(func(context.Context, *Comment, ...CallOpt) (Verdict, error) {
+			time.Sleep(d)
+			return Verdict{}, nil
+		})
+	}
+	evaluator := NewShadowEvaluator(slow(50*time.Millisecond), slow(50*time.Millisecond), ShadowConfig{})
+
+	start := time.Now()
+	_, _ = evaluator.CheckVerdict(context.Background(), &Comment{})
+	if took := time.Since(start); took >= 100*time.Millisecond {
+		t.Errorf("Expected checkers to run concurrently, but check took %v", took)
+	}
+	evaluator.Wait()
+	stats := evaluator.Stats()
+	if stats.AgreementRate() != 1 {
+		t.Errorf("Expected agreement rate to be 1, but got %v", stats.AgreementRate())
+	}
+	if stats.ShadowLatency.Mean() < 50*time.Millisecond || stats.ShadowLatency.Min != stats.ShadowLatency.Max {
+		t.Errorf("Expected shadow latency of at least 50ms, but got '%+v'", stats.ShadowLatency)
+	}
+}
+
+func TestShadowEvaluatorSlowShadow(t *testing.T) {
+	release := make(chan struct{})
+	shadow := CheckerFunc(func(ctx context.Context, _ *Comment, _ ...CallOpt) (Verdict, error) {
+		<-release
+		return Verdict{Spam: true}, ctx.Err()
+	})
+	evaluator := NewShadowEvaluator(staticChecker(Verdict{}, nil), shadow, ShadowConfig{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	verdict, err := evaluator.CheckVerdict(ctx, &Comment{})
+	if err != nil || verdict != (Verdict{}) {
+		t.Errorf("Expected primary verdict to be returned before shadow is done, but got '%+v', '%v'", verdict, err)
+	}
+	if stats := evaluator.Stats(); stats.Compared != 0 || stats.PrimaryLatency.Count != 1 {
+		t.Errorf("Expected only primary to be done, but got '%+v'", stats)
+	}
+
+	// shadow check is detached from cancellation of the primary one
+	cancel()
+	close(release)
+	evaluator.Wait()
+	stats := evaluator.Stats()
+	if stats.Compared != 1 || stats.ShadowSpam != 1 || stats.ShadowErrors != 0 {
+		t.Errorf("Expected shadow verdict to be compared after it's done, but got '%+v'", stats)
+	}
+}
+
+func TestShadowEvaluatorShadowTimeout(t *testing.T) {
+	shadow := CheckerFunc(func(ctx context.Context, _ *Comment, _ ...CallOpt) (Verdict, error) {
+		<-ctx.Done()
+		return Verdict{}, ctx.Err()
+	})
+	var shadowErr error
+	evaluator := NewShadowEvaluator(staticChecker(Verdict{}, nil), shadow, ShadowConfig{
+		ShadowTimeout: 10 * time.Millisecond,
+		OnShadowError: func(_ *Comment, err error) { shadowErr = err },
+	})
+
+	_, _ = evaluator.CheckVerdict(context.Background(), &Comment{})
+	evaluator.Wait()
+	if shadowErr != context.DeadlineExceeded {
+		t.Errorf("Expected shadow error to be '%v', but got '%v'", context.DeadlineExceeded, shadowErr)
+	}
+	if stats := evaluator.Stats(); stats.ShadowErrors != 1 {
+		t.Errorf("Expected shadow error to be counted, but got '%+v'", stats)
+	}
+}
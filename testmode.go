@@ -0,0 +1,63 @@
+package akismet
+
+import (
+	"context"
+	stderr "errors"
+)
+
+var (
+	// ErrProductionKeyInTestMode indicates that client in test mode was created with one of production API keys.
+	ErrProductionKeyInTestMode = stderr.New("production API key used in test mode")
+	// ErrProductionKeysRequired indicates that test mode has no production keys to check against, and the check wasn't
+	// explicitly disabled.
+	ErrProductionKeysRequired = stderr.New("production API keys are required in test mode")
+)
+
+// TestMode configures client to mark all comments as test ones (is_test=1), so staging traffic doesn't train Akismet.
+type TestMode struct {
+	// Tag, when set, is recorded in audit log and cassette, to make test traffic easy to identify. It's not sent to
+	// Akismet.
+	Tag string
+	// ProductionKeys lists API keys used in production, client in test mode refuses to be created with any of them.
+	// It's required, unless AllowProductionKeys is set.
+	ProductionKeys []string
+	// AllowProductionKeys disables the production key check.
+	AllowProductionKeys bool
+}
+
+// WithTestMode is client functional option enabling test mode.
+func WithTestMode(mode TestMode) OptFn {
+	return func(c *akismetClient) {
+		c.testMode = &mode
+	}
+}
+
+// checkKey returns error when given key is listed as production one, or when there are no production keys to check
+// against, unless production keys are explicitly allowed.
+func (m *TestMode) checkKey(key string) error {
+	if m.AllowProductionKeys {
+		return nil
+	}
+	if len(m.ProductionKeys) == 0 {
+		return ErrProductionKeysRequired
+	}
+	for _, productionKey := range m.ProductionKeys {
+		if key == productionKey {
+			return ErrProductionKeyInTestMode
+		}
+	}
+	return nil
+}
+
+type testTagKey struct{}
+
+// withTestTag returns context carrying test mode tag, so it can be recorded by transports, i.e. cassette.
+func withTestTag(ctx context.Context, tag string) context.Context {
+	return context.WithValue(ctx, testTagKey{}, tag)
+}
+
+// testTag returns test mode tag carried by context.
+func testTag(ctx context.Context) string {
+	tag, _ := ctx.Value(testTagKey{}).(string)
+	return tag
+}
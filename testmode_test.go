@@ -0,0 +1,172 @@
+package akismet
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestAkismetWithTestMode(t *testing.T) {
+	type check func(payload []byte, err error, t *testing.T)
+	checks := func(cs ...check) []check { return cs }
+
+	hasCauseError := func(exp error) check {
+		return func(_ []byte, err error, t *testing.T) {
+			t.Helper()
+			if errors.Cause(err) != exp {
+				t.Errorf("Expected error cause to be '%v', but got '%v'", exp, err)
+			}
+		}
+	}
+	hasPayload := func(expPayload string) check {
+		return func(payload []byte, _ error, t *testing.T) {
+			t.Helper()
+			if string(payload) != expPayload {
+				t.Errorf("Expected requst payload to be \n'%s', but got \n'%s'", expPayload, string(payload))
+			}
+		}
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		mode    TestMode
+		comment *Comment
+		checks  []check
+	}{{
+		name:    "success forcing is_test",
+		key:     "deadbeef",
+		mode:    TestMode{ProductionKeys: []string{"c0ffee"}},
+		comment: &Comment{UserIP: "0.0.0.0", UserAgent: "Mozilla/6.16", IsTest: "0"},
+		checks: checks(
+			hasCauseError(nil),
+			hasPayload("blog=http%3A%2F%2Fsome-blog.com&is_test=1&user_agent=Mozilla%2F6.16&user_ip=0.0.0.0"),
+		),
+	}, {
+		name:    "success tagging requests",
+		key:     "deadbeef",
+		mode:    TestMode{Tag: "staging", ProductionKeys: []string{"c0ffee"}},
+		comment: &Comment{UserIP: "0.0.0.0", UserAgent: "Mozilla/6.16"},
+		checks: checks(
+			hasCauseError(nil),
+			hasPayload("blog=http%3A%2F%2Fsome-blog.com&is_test=1&user_agent=Mozilla%2F6.16&user_ip=0.0.0.0"),
+		),
+	}, {
+		name:    "success with explicitly allowed production key",
+		key:     "c0ffee",
+		mode:    TestMode{ProductionKeys: []string{"c0ffee"}, AllowProductionKeys: true},
+		comment: &Comment{UserIP: "0.0.0.0", UserAgent: "Mozilla/6.16"},
+		checks: checks(
+			hasCauseError(nil),
+			hasPayload("blog=http%3A%2F%2Fsome-blog.com&is_test=1&user_agent=Mozilla%2F6.16&user_ip=0.0.0.0"),
+		),
+	}, {
+		name: "error when production key is used",
+		key:  "c0ffee",
+		mode: TestMode{ProductionKeys: []string{"c0ffee"}},
+		checks: checks(
+			hasCauseError(ErrProductionKeyInTestMode),
+			hasPayload(""),
+		),
+	}, {
+		name: "error when production keys are not listed",
+		key:  "deadbeef",
+		mode: TestMode{Tag: "staging"},
+		checks: checks(
+			hasCauseError(ErrProductionKeysRequired),
+			hasPayload(""),
+		),
+	}, {
+		name:    "success without production keys when explicitly allowed",
+		key:     "deadbeef",
+		mode:    TestMode{AllowProductionKeys: true},
+		comment: &Comment{UserIP: "0.0.0.0", UserAgent: "Mozilla/6.16"},
+		checks: checks(
+			hasCauseError(nil),
+			hasPayload("blog=http%3A%2F%2Fsome-blog.com&is_test=1&user_agent=Mozilla%2F6.16&user_ip=0.0.0.0"),
+		),
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var payload []byte
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer r.Body.Close()
+				payload, _ = ioutil.ReadAll(r.Body)
+				fmt.Fprint(w, "false")
+			}))
+			defer ts.Close()
+
+			cli, err := NewAkismet(tt.key, "http://some-blog.com", WithTestMode(tt.mode))
+			if err == nil {
+				cli.akismetUrl = ts.URL + "/%s/%s"
+				_, err = cli.Check(context.Background(), tt.comment)
+			}
+			for _, ch := range tt.checks {
+				ch(payload, err, t)
+			}
+		})
+	}
+}
+
+func TestTestModeTagIsKeptClientSide(t *testing.T) {
+	recorder := &Cassette{mode: ModeRecord}
+	sink := &auditSinkMock{}
+	transport := &transportMock{
+		roundTripResp: &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(strings.NewReader("false")),
+		},
+	}
+	cli, err := NewAkismet("deadbeef", "http://some-blog.com",
+		WithTransport(transport),
+		WithCassette(recorder),
+		WithAudit(sink, nil),
+		WithTestMode(TestMode{Tag: "staging", ProductionKeys: []string{"c0ffee"}}),
+	)
+	if err != nil {
+		t.Fatalf("got error creating client: '%v'", err)
+	}
+	if _, err := cli.Check(context.Background(), &Comment{UserIP: "0.0.0.0", UserAgent: "Mozilla/6.16"}); err != nil {
+		t.Fatalf("got error checking comment: '%v'", err)
+	}
+
+	if len(recorder.Interactions) != 1 || recorder.Interactions[0].Tag != "staging" {
+		t.Errorf("Expected cassette interaction to be tagged, but got '%+v'", recorder.Interactions)
+	}
+	if strings.Contains(recorder.Interactions[0].Form, "staging") {
+		t.Errorf("Expected tag not to be sent, but got form '%s'", recorder.Interactions[0].Form)
+	}
+	if len(sink.entries) != 1 || sink.entries[0].TestTag != "staging" {
+		t.Errorf("Expected audit entry to be tagged, but got '%+v'", sink.entries)
+	}
+}
+
+func TestTestModeFlagIsNotRedacted(t *testing.T) {
+	var payload []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ = ioutil.ReadAll(r.Body)
+		fmt.Fprint(w, spamHamResponse)
+	}))
+	defer ts.Close()
+
+	cli, err := NewAkismet("deadbeef", "http://some-blog.com",
+		WithTestMode(TestMode{ProductionKeys: []string{"c0ffee"}}),
+		WithRedaction(RedactionPolicy{"is_test": Drop()}, nil),
+	)
+	if err != nil {
+		t.Fatalf("got error creating client: '%v'", err)
+	}
+	cli.akismetUrl = ts.URL + "/%s/%s"
+	if err := cli.SubmitSpam(context.Background(), &Comment{UserIP: "1.1.1.1", UserAgent: "x"}, WithParam("is_test", "0")); err != nil {
+		t.Fatalf("got error submitting comment: '%v'", err)
+	}
+	if exp := "blog=http%3A%2F%2Fsome-blog.com&is_test=1&user_agent=x&user_ip=1.1.1.1"; string(payload) != exp {
+		t.Errorf("Expected payload to be \n'%s', but got \n'%s'", exp, payload)
+	}
+}
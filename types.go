@@ -1,82 +1,187 @@
 package akismet
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"net/url"
+	"reflect"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 )
 
-// Comment struct represents all information that will be send to endpoint.
+// Comment struct represents all information that will be send to endpoint. Struct tags hold names of Akismet's
+// parameters, which are also used in JSON representation. Form parameters marked with omitempty are not sent when empty.
 type Comment struct {
-	UserIP        string
-	UserAgent     string
-	Referrer      string
-	Permalink     string
-	Type          string
-	Author        string
-	AuthorEmail   string
-	AuthorURL     string
-	Content       string
-	Language      string
-	Charset       string
-	UserRole      string
-	Created       string
-	Modified      string
-	IsTest        string
-	RecheckReason string
+	UserIP        string `json:"user_ip" form:"user_ip"`
+	UserAgent     string `json:"user_agent" form:"user_agent"`
+	Referrer      string `json:"referrer,omitempty" form:"referrer,omitempty"`
+	Permalink     string `json:"permalink,omitempty" form:"permalink,omitempty"`
+	Type          string `json:"comment_type,omitempty" form:"comment_type,omitempty"`
+	Author        string `json:"comment_author,omitempty" form:"comment_author,omitempty"`
+	AuthorEmail   string `json:"comment_author_email,omitempty" form:"comment_author_email,omitempty"`
+	AuthorURL     string `json:"comment_author_url,omitempty" form:"comment_author_url,omitempty"`
+	Content       string `json:"comment_content,omitempty" form:"comment_content,omitempty"`
+	Language      string `json:"blog_lang,omitempty" form:"blog_lang,omitempty"`
+	Charset       string `json:"blog_charset,omitempty" form:"blog_charset,omitempty"`
+	UserRole      string `json:"user_role,omitempty" form:"user_role,omitempty"`
+	Created       string `json:"comment_date_gmt,omitempty" form:"comment_date_gmt,omitempty"`
+	Modified      string `json:"comment_post_modified_gmt,omitempty" form:"comment_post_modified_gmt,omitempty"`
+	IsTest        string `json:"is_test,omitempty" form:"is_test,omitempty"`
+	RecheckReason string `json:"recheck_reason,omitempty" form:"recheck_reason,omitempty"`
+	// HoneypotFieldName is a name of hidden form field that should be left empty, its value is sent to Akismet as
+	// parameter with the same name.
+	HoneypotFieldName string `json:"honeypot_field_name,omitempty" form:"honeypot_field_name,omitempty"`
+	HoneypotValue     string `json:"honeypot_value,omitempty" form:"-"`
+	// Context holds texts surrounding the comment, i.e. post title or parent comment, see CommentContext.
+	Context []string `json:"comment_context,omitempty" form:"comment_context[],omitempty"`
 }
 
-func (c *Comment) toValues() *url.Values {
-	p := &url.Values{}
-	p.Add("user_ip", c.UserIP)
-	p.Add("user_agent", c.UserAgent)
-	if c.Referrer != "" {
-		p.Add("referrer", c.Referrer)
-	}
-	if c.Permalink != "" {
-		p.Add("permalink", c.Permalink)
-	}
-	if c.Type != "" {
-		p.Add("comment_type", c.Type)
-	}
-	if c.Author != "" {
-		p.Add("comment_author", c.Author)
-	}
-	if c.AuthorEmail != "" {
-		p.Add("comment_author_email", c.AuthorEmail)
-	}
-	if c.AuthorURL != "" {
-		p.Add("comment_author_url", c.AuthorURL)
+// comment has the same fields as Comment, but not its methods, so it can be used with the standard JSON encoding.
+type comment Comment
+
+// MarshalJSON implements json.Marshaler, fields are named after Akismet's parameters.
+func (c Comment) MarshalJSON() ([]byte, error) {
+	return json.Marshal(comment(c))
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Besides Akismet's parameter names, Go field names (used before struct
+// tags were introduced) are accepted as well, so previously stored comments can still be read. When several keys
+// match the field, Akismet's parameter name wins, then exact Go field name, then first case-insensitive match in
+// sorted order.
+func (c *Comment) UnmarshalJSON(data []byte) error {
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, (*comment)(c)); err != nil {
+		return err
 	}
-	if c.Content != "" {
-		p.Add("comment_content", c.Content)
+
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
 	}
-	if c.Language != "" {
-		p.Add("blog_lang", c.Language)
+	sort.Strings(keys)
+
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if _, ok := raw[tagName(field.Tag.Get("json"))]; ok {
+			continue
+		}
+		value, ok := legacyValue(raw, keys, field.Name)
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(value, v.Field(i).Addr().Interface()); err != nil {
+			return errors.Wrapf(err, "cannot decode field %s", field.Name)
+		}
 	}
-	if c.Charset != "" {
-		p.Add("blog_charset", c.Charset)
+	return nil
+}
+
+// legacyValue returns value stored under Go field name, or under first of sorted keys matching it case-insensitively.
+func legacyValue(raw map[string]json.RawMessage, keys []string, name string) (json.RawMessage, bool) {
+	if value, ok := raw[name]; ok {
+		return value, true
+	}
+	for _, key := range keys {
+		if strings.EqualFold(key, name) {
+			return raw[key], true
+		}
 	}
-	if c.UserRole != "" {
-		p.Add("user_role", c.UserRole)
+	return nil, false
+}
+
+// CommentFromValues is the inverse of the comment serialization made before request is sent, parameters not related
+// to comment (i.e. blog) are ignored.
+func CommentFromValues(values url.Values) *Comment {
+	c := &Comment{}
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := tagName(t.Field(i).Tag.Get("form"))
+		if name == "-" {
+			continue
+		}
+		switch v.Field(i).Kind() {
+		case reflect.String:
+			v.Field(i).SetString(values.Get(name))
+		case reflect.Slice:
+			v.Field(i).Set(reflect.ValueOf(values[name]))
+		}
 	}
-	if c.Created != "" {
-		p.Add("comment_date_gmt", c.Created)
+	if c.HoneypotFieldName != "" {
+		c.HoneypotValue = values.Get(c.HoneypotFieldName)
 	}
-	if c.Modified != "" {
-		p.Add("comment_post_modified_gmt", c.Modified)
+	return c
+}
+
+// Fingerprint returns hex encoded SHA-256 of fields identifying the comment (sender, place and content), so the same
+// comment can be recognized when it's sent again, i.e. as a feedback.
+func (c *Comment) Fingerprint() string {
+	h := sha256.New()
+	for _, field := range []string{c.UserIP, c.UserAgent, c.Permalink, c.Author, c.AuthorEmail, c.AuthorURL, c.Content, c.Created} {
+		h.Write([]byte(field))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// reservedParams holds names of parameters set by the client itself: API key, blog, and comment's fields.
+var reservedParams = func() map[string]bool {
+	params := map[string]bool{"key": true, "blog": true}
+	t := reflect.TypeOf(Comment{})
+	for i := 0; i < t.NumField(); i++ {
+		if name := tagName(t.Field(i).Tag.Get("form")); name != "-" {
+			params[name] = true
+		}
 	}
-	if c.IsTest != "" {
-		p.Add("is_test", c.IsTest)
+	return params
+}()
+
+// tagName returns name part of struct tag value.
+func tagName(tag string) string {
+	return strings.Split(tag, ",")[0]
+}
+
+// toValues serializes comment to Akismet's parameters named after form struct tags, it's the inverse of
+// CommentFromValues.
+func (c *Comment) toValues() *url.Values {
+	p := &url.Values{}
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("form")
+		name := tagName(tag)
+		if name == "-" {
+			continue
+		}
+		omitEmpty := strings.HasSuffix(tag, ",omitempty")
+		switch field := v.Field(i); field.Kind() {
+		case reflect.String:
+			if field.String() != "" || !omitEmpty {
+				p.Add(name, field.String())
+			}
+		case reflect.Slice:
+			for j := 0; j < field.Len(); j++ {
+				p.Add(name, field.Index(j).String())
+			}
+		}
 	}
-	if c.RecheckReason != "" {
-		p.Add("recheck_reason", c.RecheckReason)
+	if c.HoneypotFieldName != "" {
+		p.Add(c.HoneypotFieldName, c.HoneypotValue)
 	}
 	return p
 }
 
-// Validate checks if user ip and user agent are present, and if present validates create/update dates.
+// Validate checks if user ip and user agent are present, if present validates create/update dates, and checks that
+// honeypot field isn't named after parameter sent by client (ErrReservedFieldName).
 func (c *Comment) Validate() error {
 	if c.UserIP == "" {
 		return errors.New("field user ip is required")
@@ -96,5 +201,8 @@ func (c *Comment) Validate() error {
 			return errors.Wrap(err, "cannot parse modified date")
 		}
 	}
+	if reservedParams[c.HoneypotFieldName] {
+		return errors.Wrapf(ErrReservedFieldName, "honeypot field '%s'", c.HoneypotFieldName)
+	}
 	return nil
 }
@@ -1,6 +1,8 @@
 package akismet
 
 import (
+	"encoding/json"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -69,6 +71,27 @@ func TestCommentValidate(t *testing.T) {
 		checks: checks(
 			hasErrorMsg(`cannot parse modified date: parsing time "asdad" as "2006-01-02T15:04:05Z07:00": cannot parse "asdad" as "2006"`),
 		),
+	}, {
+		name: "error on honeypot field named after comment parameter",
+		comment: &Comment{
+			UserIP:            "8.8.8.8",
+			UserAgent:         "Mozilla/6.1.6",
+			HoneypotFieldName: "user_ip",
+			HoneypotValue:     "9.9.9.9",
+		},
+		checks: checks(
+			hasErrorMsg("honeypot field 'user_ip': field name is reserved"),
+		),
+	}, {
+		name: "error on honeypot field named after client parameter",
+		comment: &Comment{
+			UserIP:            "8.8.8.8",
+			UserAgent:         "Mozilla/6.1.6",
+			HoneypotFieldName: "blog",
+		},
+		checks: checks(
+			hasErrorMsg("honeypot field 'blog': field name is reserved"),
+		),
 	}, {
 		name:    "success on valid comment",
 		comment: validComment,
@@ -85,3 +108,151 @@ func TestCommentValidate(t *testing.T) {
 		})
 	}
 }
+
+func filledComment() *Comment {
+	return &Comment{
+		UserIP:            "1",
+		UserAgent:         "2",
+		Referrer:          "3",
+		Permalink:         "4",
+		Type:              "5",
+		Author:            "6",
+		AuthorEmail:       "7",
+		AuthorURL:         "8",
+		Content:           "9",
+		Language:          "10",
+		Charset:           "11",
+		UserRole:          "12",
+		Created:           time.Date(2019, 6, 30, 13, 43, 12, 0, time.UTC).Format(time.RFC3339),
+		Modified:          time.Date(2019, 6, 30, 14, 43, 12, 0, time.UTC).Format(time.RFC3339),
+		IsTest:            "13",
+		RecheckReason:     "14",
+		HoneypotFieldName: "15",
+		HoneypotValue:     "16",
+		Context:           []string{"17", "18"},
+	}
+}
+
+func TestCommentFromValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		comment *Comment
+	}{{
+		name:    "round trip of empty comment",
+		comment: &Comment{},
+	}, {
+		name:    "round trip of comment with all fields set",
+		comment: filledComment(),
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := tt.comment.toValues()
+			values.Add("blog", "http://some-blog.com")
+			result := CommentFromValues(*values)
+			if !reflect.DeepEqual(tt.comment, result) {
+				t.Errorf("Expected comment to be '%+v', but got '%+v'", tt.comment, result)
+			}
+		})
+	}
+}
+
+func TestCommentJSON(t *testing.T) {
+	type check func(result *Comment, data []byte, err error, t *testing.T)
+	checks := func(cs ...check) []check { return cs }
+
+	hasNoError := func(_ *Comment, _ []byte, err error, t *testing.T) {
+		t.Helper()
+		if err != nil {
+			t.Errorf("Expected error to be nil, but got '%v'", err)
+		}
+	}
+	hasErrorMsg := func(expMsg string) check {
+		return func(_ *Comment, _ []byte, err error, t *testing.T) {
+			t.Helper()
+			if err == nil || err.Error() != expMsg {
+				t.Errorf("Expected error cause to be '%v', but got '%v'", expMsg, err)
+			}
+		}
+	}
+	hasComment := func(exp *Comment) check {
+		return func(result *Comment, _ []byte, _ error, t *testing.T) {
+			t.Helper()
+			if !reflect.DeepEqual(exp, result) {
+				t.Errorf("Expected comment to be '%+v', but got '%+v'", exp, result)
+			}
+		}
+	}
+	hasJSON := func(exp string) check {
+		return func(_ *Comment, data []byte, _ error, t *testing.T) {
+			t.Helper()
+			if string(data) != exp {
+				t.Errorf("Expected JSON to be \n'%s', but got \n'%s'", exp, data)
+			}
+		}
+	}
+
+	tests := []struct {
+		name   string
+		data   string
+		checks []check
+	}{{
+		name: "success decoding Akismet's parameter names",
+		data: `{"user_ip":"1.2.3.4","user_agent":"Mozilla/6.16","comment_author":"John Doe","blog_lang":"en"}`,
+		checks: checks(
+			hasNoError,
+			hasComment(&Comment{UserIP: "1.2.3.4", UserAgent: "Mozilla/6.16", Author: "John Doe", Language: "en"}),
+			hasJSON(`{"user_ip":"1.2.3.4","user_agent":"Mozilla/6.16","comment_author":"John Doe","blog_lang":"en"}`),
+		),
+	}, {
+		name: "success decoding legacy field names",
+		data: `{"UserIP":"1.2.3.4","UserAgent":"Mozilla/6.16","author":"John Doe","Referrer":"http://some-blog.com"}`,
+		checks: checks(
+			hasNoError,
+			hasComment(&Comment{UserIP: "1.2.3.4", UserAgent: "Mozilla/6.16", Author: "John Doe", Referrer: "http://some-blog.com"}),
+			hasJSON(`{"user_ip":"1.2.3.4","user_agent":"Mozilla/6.16","referrer":"http://some-blog.com","comment_author":"John Doe"}`),
+		),
+	}, {
+		name: "Akismet's parameter names take precedence over legacy field names",
+		data: `{"user_ip":"1.2.3.4","UserIP":"4.3.2.1","user_agent":"Mozilla/6.16"}`,
+		checks: checks(
+			hasNoError,
+			hasComment(&Comment{UserIP: "1.2.3.4", UserAgent: "Mozilla/6.16"}),
+		),
+	}, {
+		name: "exact legacy field name takes precedence over case-insensitive match",
+		data: `{"userip":"4.3.2.1","UserIP":"1.2.3.4","USERIP":"9.9.9.9","user_agent":"Mozilla/6.16"}`,
+		checks: checks(
+			hasNoError,
+			hasComment(&Comment{UserIP: "1.2.3.4", UserAgent: "Mozilla/6.16"}),
+		),
+	}, {
+		name: "first case-insensitive match in sorted order is used",
+		data: `{"userip":"4.3.2.1","USERIP":"1.2.3.4","user_agent":"Mozilla/6.16"}`,
+		checks: checks(
+			hasNoError,
+			hasComment(&Comment{UserIP: "1.2.3.4", UserAgent: "Mozilla/6.16"}),
+		),
+	}, {
+		name: "error when legacy field has invalid type",
+		data: `{"Author":1}`,
+		checks: checks(
+			hasErrorMsg("cannot decode field Author: json: cannot unmarshal number into Go value of type string"),
+		),
+	}, {
+		name: "error on invalid JSON",
+		data: `{"user_ip":`,
+		checks: checks(
+			hasErrorMsg("unexpected end of JSON input"),
+		),
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &Comment{}
+			err := json.Unmarshal([]byte(tt.data), result)
+			data, _ := json.Marshal(result)
+			for _, ch := range tt.checks {
+				ch(result, data, err, t)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package akismet
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	guidHeader      = "X-akismet-guid"
+	proTipHeader    = "X-akismet-pro-tip"
+	debugHelpHeader = "X-akismet-debug-help"
+	recheckHeader   = "X-akismet-recheck-after"
+
+	// ProTipDiscard is pro-tip value sent by Akismet when comment is blatant spam and can be safely discarded.
+	ProTipDiscard = "discard"
+)
+
+// Verdict represents result of comment check, along with additional information sent by Akismet in response headers.
+type Verdict struct {
+	Spam bool
+	// GUID identifies the check call, it can be used when reporting problems with given verdict to Akismet.
+	GUID string
+	// ProTip holds Akismet's advice, i.e. "discard" when comment is blatant spam.
+	ProTip string
+	// DebugHelp holds Akismet's hint about the reason of unusual response.
+	DebugHelp string
+	// RecheckAfter is set when Akismet advises to check comment again after given time.
+	RecheckAfter time.Duration
+	// Reason is set when verdict was made locally, i.e. by pre-filter rule, and describes why.
+	Reason string
+	// Score is optional probability of spam, in range from 0 to 1, set by checkers that have it. When it's zero, score
+	// is derived from Spam, see SpamScore.
+	Score float64
+	// Undecided is set by checkers that have no opinion about comment, i.e. when none of rules matched it. Spam and
+	// Score of undecided verdict are meaningless, it's neither spam nor ham.
+	Undecided bool
+}
+
+// SpamScore returns Score when it's set, 1 for spam and 0 for ham otherwise. Undecided verdict has score of 0.5, as
+// it's neither spam nor ham, but it should rather be skipped, as combinators do.
+func (v Verdict) SpamScore() float64 {
+	if v.Undecided {
+		return 0.5
+	}
+	if v.Score != 0 {
+		return v.Score
+	}
+	if v.Spam {
+		return 1
+	}
+	return 0
+}
+
+// Discard returns true when Akismet advised that comment can be discarded without any moderation.
+func (v Verdict) Discard() bool {
+	return v.ProTip == ProTipDiscard
+}
+
+func newVerdict(header http.Header) Verdict {
+	verdict := Verdict{
+		GUID:      header.Get(guidHeader),
+		ProTip:    header.Get(proTipHeader),
+		DebugHelp: header.Get(debugHelpHeader),
+	}
+	if seconds, err := strconv.Atoi(header.Get(recheckHeader)); err == nil && seconds > 0 {
+		verdict.RecheckAfter = time.Duration(seconds) * time.Second
+	}
+	return verdict
+}
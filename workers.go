@@ -0,0 +1,65 @@
+package akismet
+
+import (
+	"context"
+	"sync"
+)
+
+// poolOptions configures runWorkers.
+type poolOptions struct {
+	// concurrency is a number of jobs processed at the same time, defaults to 1.
+	concurrency int
+	// rateLimit is a maximum number of jobs started per second, zero means no limit.
+	rateLimit float64
+}
+
+// runWorkers processes jobs with bounded concurrency and rate. Jobs are produced by feed, which passes them to send
+// (blocking until job is taken, and returning error when ctx is done), in separate goroutine. Each job is processed by
+// work, and its result is passed to collect, in the calling goroutine, in order of completion. Error returned by feed
+// is returned after all jobs are done.
+func runWorkers[J, R any](ctx context.Context, opts poolOptions, feed func(send func(J) error) error, work func(J) R, collect func(R)) error {
+	concurrency := opts.concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	lim := newLimiter(opts.rateLimit)
+	defer lim.stop()
+
+	jobs := make(chan J)
+	results := make(chan R)
+	var feedErr error
+	go func() {
+		defer close(jobs)
+		feedErr = feed(func(job J) error {
+			if err := lim.wait(ctx); err != nil {
+				return err
+			}
+			select {
+			case jobs <- job:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	wg := &sync.WaitGroup{}
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				results <- work(job)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	for result := range results {
+		collect(result)
+	}
+	return feedErr
+}